@@ -0,0 +1,452 @@
+package genmatrix
+
+import (
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// bits of statistical security of the masks in GenerateTriple
+const maskSecurityBits = 40
+
+// Additive is the space of one party's additive shares modulo Ring.N, e.g. 2^k
+// or a prime, with elements *big.Int in [0, N)
+// the shares of all parties sum to the secret, so addition and scaling by
+// public factors are local, while shares are multiplied with MultiplyAdditive
+type Additive struct {
+    Ring Zn
+    // index of the party holding the shares, from 0 to Parties-1
+    Party int
+    Parties int
+}
+
+func (s Additive) Add(a, b interface{}) (interface{}, error) {
+    return s.Ring.Add(a, b)
+}
+
+func (s Additive) Subtract(a, b interface{}) (interface{}, error) {
+    return s.Ring.Subtract(a, b)
+}
+
+func (s Additive) Multiply(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    return nil, fmt.Errorf("%w: multiplication of shares, use MultiplyAdditive", ErrNotSupported)
+}
+
+// scale by a public integer factor
+func (s Additive) Scale(a, b interface{}) (interface{}, error) {
+    return s.Ring.Scale(a, b)
+}
+
+func (s Additive) Scalarspace() bool {
+    return false
+}
+
+func (s Additive) Zero() interface{} {
+    return s.Ring.Zero()
+}
+
+func (s Additive) Negate(a interface{}) (interface{}, error) {
+    return s.Ring.Negate(a)
+}
+
+// true if s and t hold shares modulo the same N for the same number of parties
+func (s Additive) sameRing(t Additive) bool {
+    return s.Ring.N != nil && t.Ring.N != nil && s.Ring.N.Cmp(t.Ring.N) == 0 && s.Parties == t.Parties
+}
+
+// assert that a is a matrix of additive shares
+func additiveSpace(a Matrix) (Additive, error) {
+    s, ok := a.Space.(Additive)
+    if !ok || s.Ring.N == nil {
+        return Additive{}, fmt.Errorf("%w: space %T is not Additive", ErrNotSupported, a.Space)
+    }
+    return s, nil
+}
+
+// split the values of a, reduced modulo ring.N, into additive shares of n parties
+// parties 1 to n-1 get uniformly random shares and party 0 the remainder
+func shareAdditive(a Matrix, ring Zn, n int) ([]Matrix, error) {
+    rest := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        x, err := toBigint(v)
+        if err != nil {return nil, elementError(a, i, err)}
+        rest[i] = new(big.Int).Mod(x, ring.N)
+    }
+    shares := make([]Matrix, n)
+    for j := n - 1; j >= 0; j -= 1 {
+        vals := rest
+        if j > 0 {
+            vals = make([]interface{}, len(rest))
+            for i := range vals {
+                r, err := rand.Int(rand.Reader, ring.N)
+                if err != nil {return nil, err}
+                vals[i] = r
+                rest[i], _ = ring.Subtract(rest[i], r)
+            }
+        }
+        var err error
+        shares[j], err = NewMatrix(a.Rows, a.Cols, vals, Additive{ring, j, n})
+        if err != nil {return nil, err}
+    }
+    return shares, nil
+}
+
+// split the Bigint matrix a into additive shares modulo ring.N for n parties,
+// all of which are needed to reconstruct a
+// elements of a must lie within (-N/2, N/2) to be recovered, otherwise
+// ErrPlaintextOverflow is returned
+func ShareAdditive(a Matrix, ring Zn, n int) ([]Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return nil, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    if ring.N == nil || n < 1 {
+        return nil, fmt.Errorf("invalid ring or number of parties %d", n)
+    }
+    max := new(big.Int).Rsh(new(big.Int).Sub(ring.N, big.NewInt(1)), 1)
+    for i, v := range a.values {
+        x, err := toBigint(v)
+        if err != nil {return nil, elementError(a, i, err)}
+        if x.CmpAbs(max) > 0 {
+            return nil, elementError(a, i, fmt.Errorf("%w: |%v| exceeds %v", ErrPlaintextOverflow, x, max))
+        }
+    }
+    return shareAdditive(a, ring, n)
+}
+
+// reconstruct the Bigint matrix from the share matrices of all parties
+// the elements are given in (-N/2, N/2]
+func ReconstructAdditive(shares []Matrix) (Matrix, error) {
+    if len(shares) == 0 {
+        return Matrix{}, errors.New("no shares")
+    }
+    s, err := additiveSpace(shares[0])
+    if err != nil {return Matrix{}, err}
+    if len(shares) != s.Parties {
+        return Matrix{}, fmt.Errorf("got %d shares, but all %d parties are needed", len(shares), s.Parties)
+    }
+    seen := make([]bool, s.Parties)
+    sum := shares[0]
+    for i, m := range shares {
+        t, err := additiveSpace(m)
+        if err != nil {return Matrix{}, err}
+        if !s.sameRing(t) || t.Party < 0 || t.Party >= s.Parties || seen[t.Party] {
+            return Matrix{}, fmt.Errorf("share %d of party %d does not match the other shares", i, t.Party)
+        }
+        seen[t.Party] = true
+        if i > 0 {
+            sum, err = sum.Add(m)
+            if err != nil {return Matrix{}, err}
+        }
+    }
+    half := new(big.Int).Rsh(s.Ring.N, 1)
+    vals := make([]interface{}, len(sum.values))
+    for i, v := range sum.values {
+        x, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(sum, i, err)}
+        x = new(big.Int).Mod(x, s.Ring.N)
+        if x.Cmp(half) > 0 {
+            x.Sub(x, s.Ring.N)
+        }
+        vals[i] = x
+    }
+    return NewMatrix(sum.Rows, sum.Cols, vals, Bigint{})
+}
+
+// Triple is one party's shares of a matrix Beaver triple: uniformly random
+// matrices A and B, and C = A * B
+// a triple is used for a single multiplication only
+type Triple struct {
+    A, B, C Matrix
+}
+
+// uniformly random rows x cols matrix modulo ring.N, in Bigint
+func randomRingMatrix(ring Zn, rows, cols int) (Matrix, error) {
+    vals := make([]interface{}, rows*cols)
+    for i := range vals {
+        r, err := rand.Int(rand.Reader, ring.N)
+        if err != nil {return Matrix{}, err}
+        vals[i] = r
+    }
+    return NewMatrix(rows, cols, vals, Bigint{})
+}
+
+// generate a triple for multiplying rows x inner by inner x cols matrices
+// as a trusted dealer, returning the triple of each of n parties
+// the dealer learns A, B and C, so this is meant for tests
+func DealTriples(ring Zn, n, rows, inner, cols int) ([]Triple, error) {
+    if ring.N == nil || n < 1 {
+        return nil, fmt.Errorf("invalid ring or number of parties %d", n)
+    }
+    a, err := randomRingMatrix(ring, rows, inner)
+    if err != nil {return nil, err}
+    b, err := randomRingMatrix(ring, inner, cols)
+    if err != nil {return nil, err}
+    c, err := a.Multiply(b)
+    if err != nil {return nil, err}
+    a_shares, err := shareAdditive(a, ring, n)
+    if err != nil {return nil, err}
+    b_shares, err := shareAdditive(b, ring, n)
+    if err != nil {return nil, err}
+    c_shares, err := shareAdditive(c, ring, n)
+    if err != nil {return nil, err}
+    triples := make([]Triple, n)
+    for j := range triples {
+        triples[j] = Triple{a_shares[j], b_shares[j], c_shares[j]}
+    }
+    return triples, nil
+}
+
+// assert that the triple t is of party s, fitting x * y
+func checkTriple(t Triple, s Additive, x, y Matrix) error {
+    for _, m := range []Matrix{t.A, t.B, t.C} {
+        m_s, err := additiveSpace(m)
+        if err != nil {return fmt.Errorf("triple: %w", err)}
+        if !m_s.sameRing(s) || m_s.Party != s.Party {
+            return errors.New("triple is not shared with the same party and ring")
+        }
+    }
+    if t.A.Rows != x.Rows || t.A.Cols != x.Cols || t.B.Rows != y.Rows || t.B.Cols != y.Cols || t.C.Rows != x.Rows || t.C.Cols != y.Cols {
+        return fmt.Errorf("triple of %d x %d and %d x %d matrices does not fit %d x %d and %d x %d", t.A.Rows, t.A.Cols, t.B.Rows, t.B.Cols, x.Rows, x.Cols, y.Rows, y.Cols)
+    }
+    return nil
+}
+
+// assert that msg is a pair of shares of the given sizes, as sent in MultiplyAdditive
+func receivedOpenings(msg interface{}, from int, s Additive, d_rows, d_cols, e_rows, e_cols int) ([2]Matrix, error) {
+    m, ok := msg.([2]Matrix)
+    if !ok {
+        return m, fmt.Errorf("party %d sent %T, expected [2]Matrix", from, msg)
+    }
+    for _, o := range m {
+        o_s, err := additiveSpace(o)
+        if err != nil {return m, fmt.Errorf("party %d: %w", from, err)}
+        if !o_s.sameRing(s) || o_s.Party != from {
+            return m, fmt.Errorf("party %d sent shares of another ring or party", from)
+        }
+    }
+    if m[0].Rows != d_rows || m[0].Cols != d_cols || m[1].Rows != e_rows || m[1].Cols != e_cols {
+        return m, fmt.Errorf("party %d sent matrices of the wrong size", from)
+    }
+    return m, nil
+}
+
+// multiply the share matrices x and y together with the other parties,
+// consuming the Beaver triple t
+// every party calls MultiplyAdditive with its shares of x and y, its share of
+// the same triple and its transport, and gets its share of x * y
+//
+// the parties open D = x - A and E = y - B, which reveal nothing since A and B
+// are uniformly random, and x * y = C + D * B + A * E + D * E
+// the protocol protects against semi-honest parties only, and a party that
+// fails aborts the protocol for all parties, see Transport.Abort
+func MultiplyAdditive(x, y Matrix, t Triple, tr Transport, opts ...Option) (product Matrix, err error) {
+    defer abortOnError(tr, &err)
+    s, err := additiveSpace(x)
+    if err != nil {return Matrix{}, err}
+    y_s, err := additiveSpace(y)
+    if err != nil {return Matrix{}, err}
+    if !s.sameRing(y_s) || s.Party != y_s.Party {
+        return Matrix{}, errors.New("shares of x and y belong to different parties or rings")
+    }
+    if s.Party != tr.Index() || s.Parties != tr.Parties() {
+        return Matrix{}, fmt.Errorf("shares of party %d of %d used by party %d of %d", s.Party, s.Parties, tr.Index(), tr.Parties())
+    }
+    if x.Cols != y.Rows {
+        return Matrix{}, fmt.Errorf("matrices x and y are not compatible")
+    }
+    err = checkTriple(t, s, x, y)
+    if err != nil {return Matrix{}, err}
+
+    // open D and E in one round
+    d, err := x.Subtract(t.A, opts...)
+    if err != nil {return Matrix{}, err}
+    e, err := y.Subtract(t.B, opts...)
+    if err != nil {return Matrix{}, err}
+    msgs, err := broadcast(tr, [2]Matrix{d, e})
+    if err != nil {return Matrix{}, err}
+    for j, msg := range msgs {
+        m, err := receivedOpenings(msg, j, s, x.Rows, x.Cols, y.Rows, y.Cols)
+        if err != nil {return Matrix{}, err}
+        if j == 0 {
+            d, e = m[0], m[1]
+        } else {
+            d, err = d.Add(m[0], opts...)
+            if err != nil {return Matrix{}, err}
+            e, err = e.Add(m[1], opts...)
+            if err != nil {return Matrix{}, err}
+        }
+    }
+    // D and E are public
+    d.Space, e.Space = s.Ring, s.Ring
+
+    z, err := d.Multiply(t.B, opts...)
+    if err != nil {return Matrix{}, err}
+    z, err = z.Add(t.C, opts...)
+    if err != nil {return Matrix{}, err}
+    ae, err := t.A.Multiply(e, opts...)
+    if err != nil {return Matrix{}, err}
+    z, err = z.Add(ae, opts...)
+    if err != nil {return Matrix{}, err}
+    if s.Party == 0 {
+        de, err := d.Multiply(e, opts...)
+        if err != nil {return Matrix{}, err}
+        de.Space = s
+        z, err = z.Add(de, opts...)
+        if err != nil {return Matrix{}, err}
+    }
+    z.Space = s
+    return z, nil
+}
+
+// generate this party's share of a triple for multiplying rows x inner by
+// inner x cols matrices modulo ring.N, without a trusted dealer
+// every party calls GenerateTriple with the same arguments, its own key share
+// and its transport
+//
+// each party i picks random shares A_i and B_i and broadcasts an encryption of A_i
+// each party j then broadcasts an encryption of A * B_j + R_j for a random mask R_j,
+// the sum of which is jointly decrypted, and C_j = [j = 0] (A * B + R) - R_j
+// the masks hide A * B statistically, which requires a plaintext space of about
+// 2 * log2(N) + log2(inner) + 2 * log2(parties) + 40 bits, otherwise
+// ErrPlaintextOverflow is returned
+// the protocol protects against semi-honest parties only, and a party that
+// fails aborts the protocol for all parties, see Transport.Abort
+func GenerateTriple(pk DJ_public_key, sk *tcpaillier.KeyShare, ring Zn, rows, inner, cols int, tr Transport) (triple Triple, err error) {
+    defer abortOnError(tr, &err)
+    if pk.PubKey == nil || sk == nil || !samePubKey(pk.PubKey, sk.PubKey) {
+        return Triple{}, ErrKeyMismatch
+    }
+    if ring.N == nil {
+        return Triple{}, errors.New("ring modulus can't be nil")
+    }
+    n := tr.Parties()
+    if n < int(pk.K) {
+        return Triple{}, fmt.Errorf("needed %d parties to decrypt, but got %d", pk.K, n)
+    }
+    // A has entries below n * N, so A * B_j has entries below n * inner * N^2
+    n_big := big.NewInt(int64(n))
+    bound := new(big.Int).Mul(ring.N, ring.N)
+    bound.Mul(bound, big.NewInt(int64(inner))).Mul(bound, n_big)
+    mask_bits := bound.BitLen() + maskSecurityBits
+    // the sum of n products and n masks
+    max := new(big.Int).Lsh(n_big, uint(mask_bits + 1))
+    if max.Cmp(MaxSigned(pk)) >= 0 {
+        return Triple{}, fmt.Errorf("%w: %d bit masks don't fit the plaintext space", ErrPlaintextOverflow, mask_bits)
+    }
+    s := Additive{ring, tr.Index(), n}
+
+    a, err := randomRingMatrix(ring, rows, inner)
+    if err != nil {return Triple{}, err}
+    b, err := randomRingMatrix(ring, inner, cols)
+    if err != nil {return Triple{}, err}
+
+    // round 1: share encryptions of A_i
+    a_enc, err := EncryptMatrix(a, pk)
+    if err != nil {return Triple{}, err}
+    msgs, err := broadcast(tr, a_enc)
+    if err != nil {return Triple{}, err}
+    var a_sum Matrix
+    for j, msg := range msgs {
+        a_j, err := receivedCiphertext(msg, j, pk, rows, inner)
+        if err != nil {return Triple{}, err}
+        if j == 0 {
+            a_sum = a_j
+        } else {
+            a_sum, err = a_sum.Add(a_j)
+            if err != nil {return Triple{}, err}
+        }
+    }
+
+    // round 2: share encryptions of A * B_j + R_j
+    r_vals := make([]interface{}, rows*cols)
+    for i := range r_vals {
+        r_vals[i], err = tcpaillier.RandomInt(mask_bits)
+        if err != nil {return Triple{}, err}
+    }
+    r, err := NewMatrix(rows, cols, r_vals, Bigint{})
+    if err != nil {return Triple{}, err}
+    r_enc, err := EncryptMatrix(r, pk)
+    if err != nil {return Triple{}, err}
+    masked, err := a_sum.Multiply(b)
+    if err != nil {return Triple{}, err}
+    masked, err = masked.Add(r_enc)
+    if err != nil {return Triple{}, err}
+    msgs, err = broadcast(tr, masked)
+    if err != nil {return Triple{}, err}
+    for j, msg := range msgs {
+        m_j, err := receivedCiphertext(msg, j, pk, rows, cols)
+        if err != nil {return Triple{}, err}
+        if j == 0 {
+            masked = m_j
+        } else {
+            masked, err = masked.Add(m_j)
+            if err != nil {return Triple{}, err}
+        }
+    }
+
+    // round 3: jointly decrypt A * B + R
+    part, err := PartialDecryptMatrix(masked, sk)
+    if err != nil {return Triple{}, err}
+    msgs, err = broadcast(tr, part)
+    if err != nil {return Triple{}, err}
+    parts := make([]PartialDecryption, len(msgs))
+    for j, msg := range msgs {
+        var ok bool
+        parts[j], ok = msg.(PartialDecryption)
+        if !ok {
+            return Triple{}, fmt.Errorf("party %d sent %T, expected PartialDecryption", j, msg)
+        }
+    }
+    masked_plain, err := CombinePartialDecryptions(pk, parts)
+    if err != nil {return Triple{}, err}
+
+    c, err := r.Negate()
+    if err != nil {return Triple{}, err}
+    if s.Party == 0 {
+        c, err = c.Add(masked_plain)
+        if err != nil {return Triple{}, err}
+    }
+    var t Triple
+    t.A, err = ring.Reduce(a)
+    if err != nil {return Triple{}, err}
+    t.B, err = ring.Reduce(b)
+    if err != nil {return Triple{}, err}
+    t.C, err = ring.Reduce(c)
+    if err != nil {return Triple{}, err}
+    t.A.Space, t.B.Space, t.C.Space = s, s, s
+    return t, nil
+}
+
+func (s Additive) SpaceName() string {return "additive"}
+
+func (s Additive) MarshalSpace() ([]byte, error) {
+    if s.Ring.N == nil {
+        return nil, errors.New("ring modulus can't be nil")
+    }
+    var e encoder
+    e.bigint(s.Ring.N)
+    e.uvarint(uint64(s.Party))
+    e.uvarint(uint64(s.Parties))
+    return e.buf, nil
+}
+
+func (s Additive) MarshalElement(a interface{}) ([]byte, error) {return marshalBigintElement(a)}
+func (s Additive) UnmarshalElement(b []byte) (interface{}, error) {return unmarshalBigintElement(b)}
+
+func init() {
+    RegisterSpace("additive", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        n, party, parties := d.bigint(), d.uvarint(), d.uvarint()
+        if d.err != nil {return nil, d.err}
+        ring, err := NewZn(n)
+        if err != nil {return nil, err}
+        if party >= parties || parties > 1 << 31 {
+            return nil, errors.New("malformed additive sharing parameters")
+        }
+        return Additive{ring, int(party), int(parties)}, nil
+    })
+}
@@ -0,0 +1,186 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestAdditive(t *testing.T) {
+    ring2k, err := NewZn(new(big.Int).Lsh(big.NewInt(1), 64))
+    if err != nil {t.Fatal(err)}
+    ringp, err := NewZn(new(big.Int).SetUint64(NTTPrime62))
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 3, []int{1, -2, 3, 4, 5, -6})
+    if err != nil {t.Fatal(err)}
+    for name, ring := range map[string]Zn{"2^k": ring2k, "prime": ringp} {
+        t.Run(name, func(t *testing.T) {
+            for _, n := range []int{2, 4} {
+                shares, err := ShareAdditive(a, ring, n)
+                if err != nil {t.Fatal(err)}
+                b, err := ReconstructAdditive(shares)
+                if err != nil {t.Fatal(err)}
+                Compare(b, a, t)
+                // (a - a + a) * -7
+                correct, err := a.Scale(big.NewInt(-7))
+                if err != nil {t.Fatal(err)}
+                results := make([]Matrix, n)
+                for i, s := range shares {
+                    results[i], err = s.Subtract(s)
+                    if err != nil {t.Fatal(err)}
+                    results[i], err = results[i].Add(s)
+                    if err != nil {t.Fatal(err)}
+                    results[i], err = results[i].Scale(big.NewInt(-7))
+                    if err != nil {t.Fatal(err)}
+                }
+                b, err = ReconstructAdditive(results)
+                if err != nil {t.Fatal(err)}
+                Compare(b, correct, t)
+                _, err = ReconstructAdditive(shares[1:])
+                if err == nil {t.Error("reconstructed from too few shares")}
+            }
+        })
+    }
+    t.Run("local multiplication", func(t *testing.T) {
+        shares, err := ShareAdditive(a, ringp, 2)
+        if err != nil {t.Fatal(err)}
+        _, err = shares[0].Multiply(shares[0].Transpose())
+        if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+    })
+    t.Run("overflow", func(t *testing.T) {
+        small, err := NewZn(big.NewInt(16))
+        if err != nil {t.Fatal(err)}
+        _, err = ShareAdditive(a, small, 2)
+        if err != nil {t.Fatal(err)}
+        b, err := NewMatrixFromInt(1, 1, []int{8})
+        if err != nil {t.Fatal(err)}
+        _, err = ShareAdditive(b, small, 2)
+        if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+    })
+    t.Run("serialization", func(t *testing.T) {
+        shares, err := ShareAdditive(a, ring2k, 2)
+        if err != nil {t.Fatal(err)}
+        enc, err := shares[1].MarshalJSON()
+        if err != nil {t.Fatal(err)}
+        var b Matrix
+        err = b.UnmarshalJSON(enc)
+        if err != nil {t.Fatal(err)}
+        c, err := ReconstructAdditive([]Matrix{shares[0], b})
+        if err != nil {t.Fatal(err)}
+        Compare(c, a, t)
+    })
+}
+
+// multiply x and y shared among n parties with the triples of each party
+func multiplyWithTriples(x, y Matrix, ring Zn, n int, triples []Triple, t *testing.T) Matrix {
+    x_shares, err := ShareAdditive(x, ring, n)
+    if err != nil {t.Fatal(err)}
+    y_shares, err := ShareAdditive(y, ring, n)
+    if err != nil {t.Fatal(err)}
+    results, errs := runParties(n, func(tr Transport) (Matrix, error) {
+        i := tr.Index()
+        return MultiplyAdditive(x_shares[i], y_shares[i], triples[i], tr)
+    })
+    for i, err := range errs {
+        if err != nil {t.Fatalf("party %d: %v", i, err)}
+    }
+    c, err := ReconstructAdditive(results)
+    if err != nil {t.Fatal(err)}
+    return c
+}
+
+func TestMultiplyAdditive(t *testing.T) {
+    ring, err := NewZn(new(big.Int).Lsh(big.NewInt(1), 32))
+    if err != nil {t.Fatal(err)}
+    x, err := NewMatrixFromInt(2, 3, []int{1, -2, 3, 4, 5, -6})
+    if err != nil {t.Fatal(err)}
+    y, err := NewMatrixFromInt(3, 2, []int{7, 8, -9, 10, 11, 12})
+    if err != nil {t.Fatal(err)}
+    correct, err := x.Multiply(y)
+    if err != nil {t.Fatal(err)}
+    t.Run("dealer", func(t *testing.T) {
+        for _, n := range []int{2, 3} {
+            triples, err := DealTriples(ring, n, 2, 3, 2)
+            if err != nil {t.Fatal(err)}
+            Compare(multiplyWithTriples(x, y, ring, n, triples, t), correct, t)
+        }
+    })
+    t.Run("homomorphic", func(t *testing.T) {
+        cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(2, 3))
+        if err != nil {t.Fatal(err)}
+        parties := make([]Triple, 3)
+        _, errs := runParties(3, func(tr Transport) (Matrix, error) {
+            var err error
+            parties[tr.Index()], err = GenerateTriple(cs, djsks[tr.Index()], ring, 2, 3, 2, tr)
+            return Matrix{}, err
+        })
+        for i, err := range errs {
+            if err != nil {t.Fatalf("party %d: %v", i, err)}
+        }
+        // C = A * B
+        shares := func(k int) []Matrix {
+            s := make([]Matrix, len(parties))
+            for i, p := range parties {
+                s[i] = []Matrix{p.A, p.B, p.C}[k]
+            }
+            return s
+        }
+        a, err := ReconstructAdditive(shares(0))
+        if err != nil {t.Fatal(err)}
+        b, err := ReconstructAdditive(shares(1))
+        if err != nil {t.Fatal(err)}
+        c, err := ReconstructAdditive(shares(2))
+        if err != nil {t.Fatal(err)}
+        ab, err := a.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        ab, err = ring.Reduce(ab)
+        if err != nil {t.Fatal(err)}
+        c, err = ring.Reduce(c)
+        if err != nil {t.Fatal(err)}
+        Compare(c, ab, t)
+        Compare(multiplyWithTriples(x, y, ring, 3, parties, t), correct, t)
+    })
+    t.Run("plaintext too small", func(t *testing.T) {
+        cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(2, 2))
+        if err != nil {t.Fatal(err)}
+        large, err := NewZn(new(big.Int).Lsh(big.NewInt(1), 64))
+        if err != nil {t.Fatal(err)}
+        _, errs := runParties(2, func(tr Transport) (Matrix, error) {
+            _, err := GenerateTriple(cs, djsks[tr.Index()], large, 2, 3, 2, tr)
+            return Matrix{}, err
+        })
+        for i, err := range errs {
+            if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("party %d: expected ErrPlaintextOverflow, got %v", i, err)}
+        }
+    })
+    t.Run("party fails", func(t *testing.T) {
+        triples, err := DealTriples(ring, 3, 2, 3, 2)
+        if err != nil {t.Fatal(err)}
+        x_shares, err := ShareAdditive(x, ring, 3)
+        if err != nil {t.Fatal(err)}
+        y_shares, err := ShareAdditive(y, ring, 3)
+        if err != nil {t.Fatal(err)}
+        errs := runPartiesFailing(t, 3, 0, func(tr Transport) (Matrix, error) {
+            i := tr.Index()
+            return MultiplyAdditive(x_shares[i], y_shares[i], triples[i], tr)
+        })
+        for i, err := range errs[1:] {
+            if !errors.Is(err, ErrAborted) {t.Errorf("party %d: expected ErrAborted, got %v", i + 1, err)}
+        }
+    })
+    t.Run("wrong triple", func(t *testing.T) {
+        triples, err := DealTriples(ring, 2, 3, 3, 3)
+        if err != nil {t.Fatal(err)}
+        x_shares, err := ShareAdditive(x, ring, 2)
+        if err != nil {t.Fatal(err)}
+        y_shares, err := ShareAdditive(y, ring, 2)
+        if err != nil {t.Fatal(err)}
+        _, errs := runParties(2, func(tr Transport) (Matrix, error) {
+            i := tr.Index()
+            return MultiplyAdditive(x_shares[i], y_shares[i], triples[i], tr)
+        })
+        for i, err := range errs {
+            if err == nil {t.Errorf("party %d: multiplied with a triple of the wrong size", i)}
+        }
+    })
+}
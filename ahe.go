@@ -0,0 +1,311 @@
+package genmatrix
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+var (
+    // the matrix was expected to hold plaintext values in the Bigint space
+    ErrNotPlaintext = errors.New("matrix is not a plaintext Bigint matrix")
+    // the matrix was expected to hold ciphertexts in a DJ_public_key space
+    ErrNotEncrypted = errors.New("matrix is not encrypted with DJ_public_key")
+    // a key share does not belong to the public key used for encryption
+    ErrKeyMismatch = errors.New("key share does not belong to the public key")
+    // an element is not of the type required by the operation
+    ErrElementType = errors.New("element is not *big.Int")
+    // a plaintext is outside the signed range of the plaintext space, see MaxSigned
+    ErrPlaintextOverflow = errors.New("plaintext out of range")
+)
+
+// ElementError reports which matrix element an operation failed on
+type ElementError struct {
+    Row, Col int
+    Err error
+}
+
+func (e ElementError) Error() string {
+    return fmt.Sprintf("element (%d, %d): %v", e.Row, e.Col, e.Err)
+}
+
+func (e ElementError) Unwrap() error {
+    return e.Err
+}
+
+// wrap err with the position of values[i] in a
+func elementError(a Matrix, i int, err error) error {
+    return ElementError{Row: i / a.Cols, Col: i % a.Cols, Err: err}
+}
+
+// assert that an element is a non-nil *big.Int
+func toBigint(v interface{}) (*big.Int, error) {
+    b, ok := v.(*big.Int)
+    if !ok || b == nil {
+        return nil, fmt.Errorf("%w, but %T", ErrElementType, v)
+    }
+    return b, nil
+}
+
+// true if a and b describe the same Damgård-Jurik public key
+func samePubKey(a, b *tcpaillier.PubKey) bool {
+    if a == nil || b == nil {
+        return false
+    }
+    return a.N.Cmp(b.N) == 0 && a.S == b.S && a.L == b.L && a.K == b.K
+}
+
+// return the public key of an encrypted matrix
+func encryptionKey(cipher Matrix) (DJ_public_key, error) {
+    pk, ok := cipher.Space.(DJ_public_key)
+    if !ok {
+        return pk, fmt.Errorf("%w: space is %T", ErrNotEncrypted, cipher.Space)
+    }
+    if pk.PubKey == nil {
+        return pk, fmt.Errorf("%w: public key is nil", ErrNotEncrypted)
+    }
+    // the cache is filled lazily without locking, so fill it before any parallel use
+    pk.Cache()
+    return pk, nil
+}
+
+// largest absolute value of a plaintext under pk, (N^s - 1) / 2
+// results of computations on encrypted values must stay within this bound,
+// since larger values wrap around to the opposite sign
+func MaxSigned(pk DJ_public_key) *big.Int {
+    return new(big.Int).Rsh(pk.Cache().NToS, 1)
+}
+
+// map x to the plaintext space Z_{N^s} of pk, negative values to the upper half
+func EncodeSigned(pk DJ_public_key, x *big.Int) (*big.Int, error) {
+    return encodeSigned(x, pk.Cache().NToS)
+}
+
+// map a plaintext in Z_{N^s} of pk back to its signed value, the upper half to negative values
+func DecodeSigned(pk DJ_public_key, x *big.Int) *big.Int {
+    return decodeSigned(x, pk.Cache().NToS)
+}
+
+func encodeSigned(x, n_to_s *big.Int) (*big.Int, error) {
+    if new(big.Int).Lsh(new(big.Int).Abs(x), 1).Cmp(n_to_s) >= 0 {
+        return nil, fmt.Errorf("%w: %d bits for a %d bit plaintext space", ErrPlaintextOverflow, x.BitLen(), n_to_s.BitLen())
+    }
+    if x.Sign() < 0 {
+        return new(big.Int).Add(n_to_s, x), nil
+    }
+    return x, nil
+}
+
+func decodeSigned(x, n_to_s *big.Int) *big.Int {
+    if new(big.Int).Lsh(x, 1).Cmp(n_to_s) > 0 {
+        return new(big.Int).Sub(x, n_to_s)
+    }
+    return x
+}
+
+// encrypt every element of the plaintext Bigint matrix a with pk
+// negative values are encoded in the upper half of the plaintext space, see EncodeSigned
+// the elements are encrypted in parallel, see WithWorkers
+func EncryptMatrix(a Matrix, pk DJ_public_key, opts ...Option) (Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    if pk.PubKey == nil {
+        return Matrix{}, errors.New("public key can't be nil")
+    }
+    n_to_s := pk.Cache().NToS
+    b_vals := make([]interface{}, len(a.values))
+    err := parallelFor(len(b_vals), newOptions(opts), func(i int) error {
+        plain, err := toBigint(a.values[i])
+        if err != nil {return elementError(a, i, err)}
+        plain, err = encodeSigned(plain, n_to_s)
+        if err != nil {return elementError(a, i, err)}
+        b_vals[i], _, err = pk.Encrypt(plain)
+        if err != nil {return elementError(a, i, err)}
+        return nil
+    })
+    if err != nil {return Matrix{}, err}
+    return NewMatrix(a.Rows, a.Cols, b_vals, pk)
+}
+
+// EncryptMatrix, stopping with ctx.Err() once ctx is done
+func EncryptMatrixContext(ctx context.Context, a Matrix, pk DJ_public_key, opts ...Option) (Matrix, error) {
+    return EncryptMatrix(a, pk, withContext(ctx, opts)...)
+}
+
+// PartialDecryption is the decryption share of one party for a whole encrypted matrix
+type PartialDecryption struct {
+    Rows, Cols int
+    // index of the key share that produced the partial decryption
+    Index uint8
+    // fingerprint of the public key of the key share, see DJ_public_key.Fingerprint
+    Key [sha256.Size]byte
+    // digest of the ciphertext matrix, see CiphertextDigest
+    Digest [sha256.Size]byte
+    // decryption shares in row-major order
+    Shares []*tcpaillier.DecryptionShare
+    // proofs of correct decryption of each share, only set by PartialDecryptMatrixWithProof
+    Proofs []*tcpaillier.DecryptShareZK
+}
+
+// fingerprint of an encrypted matrix, covering its size, public key and ciphertexts
+func CiphertextDigest(cipher Matrix) (digest [sha256.Size]byte, err error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return}
+    h := sha256.New()
+    var buf [8]byte
+    write := func(b []byte) {
+        binary.BigEndian.PutUint64(buf[:], uint64(len(b)))
+        h.Write(buf[:])
+        h.Write(b)
+    }
+    binary.BigEndian.PutUint64(buf[:], uint64(cipher.Rows))
+    h.Write(buf[:])
+    binary.BigEndian.PutUint64(buf[:], uint64(cipher.Cols))
+    h.Write(buf[:])
+    write(pk.N.Bytes())
+    write([]byte{pk.S})
+    for i, v := range cipher.values {
+        c, err := toBigint(v)
+        if err != nil {return digest, elementError(cipher, i, err)}
+        write(c.Bytes())
+    }
+    copy(digest[:], h.Sum(nil))
+    return
+}
+
+// partially decrypt the encrypted matrix cipher with a single key share
+// threshold many partial decryptions are joined with CombinePartialDecryptions
+func PartialDecryptMatrix(cipher Matrix, sk *tcpaillier.KeyShare, opts ...Option) (part PartialDecryption, err error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return}
+    if sk == nil || !samePubKey(sk.PubKey, pk.PubKey) {
+        err = ErrKeyMismatch
+        return
+    }
+    part.Digest, err = CiphertextDigest(cipher)
+    if err != nil {return}
+    part.Rows, part.Cols, part.Index = cipher.Rows, cipher.Cols, sk.Index
+    part.Key = pk.Fingerprint()
+    sk.Cache()
+    part.Shares = make([]*tcpaillier.DecryptionShare, len(cipher.values))
+    err = parallelFor(len(part.Shares), newOptions(opts), func(i int) (err error) {
+        part.Shares[i], err = sk.PartialDecrypt(cipher.values[i].(*big.Int))
+        if err != nil {return elementError(cipher, i, err)}
+        return
+    })
+    return
+}
+
+// join partial decryptions from at least threshold many distinct key shares
+// into the plaintext Bigint matrix, with signed values as given by DecodeSigned
+func CombinePartialDecryptions(pk DJ_public_key, parts []PartialDecryption, opts ...Option) (Matrix, error) {
+    if pk.PubKey == nil {
+        return Matrix{}, errors.New("public key can't be nil")
+    }
+    if pk.S != 1 {
+        return Matrix{}, fmt.Errorf("%w: tcpaillier only decrypts with s = 1, but s is %d", ErrNotSupported, pk.S)
+    }
+    if len(parts) == 0 {
+        return Matrix{}, fmt.Errorf("needed %d partial decryptions, but got none", pk.K)
+    }
+    // validate every part before choosing threshold many distinct ones
+    key := pk.Fingerprint()
+    first := parts[0]
+    distinct := make([]PartialDecryption, 0, len(parts))
+    seen := make(map[uint8]bool)
+    for _, part := range parts {
+        if part.Key != key {
+            return Matrix{}, fmt.Errorf("%w: partial decryption %d", ErrKeyMismatch, part.Index)
+        }
+        if part.Index < 1 || int(part.Index) > int(pk.L) {
+            return Matrix{}, fmt.Errorf("no key share with index %d", part.Index)
+        }
+        if part.Rows != first.Rows || part.Cols != first.Cols {
+            return Matrix{}, fmt.Errorf("dimension mismatch in partial decryptions: %d x %d != %d x %d", part.Rows, part.Cols, first.Rows, first.Cols)
+        }
+        if part.Digest != first.Digest {
+            return Matrix{}, fmt.Errorf("partial decryptions %d and %d are of different ciphertexts", first.Index, part.Index)
+        }
+        if len(part.Shares) != part.Rows*part.Cols {
+            return Matrix{}, fmt.Errorf("partial decryption %d has %d shares for a %d x %d matrix", part.Index, len(part.Shares), part.Rows, part.Cols)
+        }
+        if !seen[part.Index] {
+            seen[part.Index] = true
+            distinct = append(distinct, part)
+        }
+    }
+    if len(distinct) < int(pk.K) {
+        return Matrix{}, fmt.Errorf("needed %d distinct partial decryptions, but got %d", pk.K, len(distinct))
+    }
+    parts = distinct[:pk.K]
+    n_to_s := pk.Cache().NToS
+    plain_vals := make([]interface{}, len(first.Shares))
+    err := parallelFor(len(plain_vals), newOptions(opts), func(i int) (err error) {
+        shares := make([]*tcpaillier.DecryptionShare, len(parts))
+        for j, part := range parts {
+            shares[j] = part.Shares[i]
+            if shares[j] == nil || shares[j].Index != part.Index {
+                return fmt.Errorf("malformed partial decryption %d at element %d", part.Index, i)
+            }
+        }
+        plain, err := pk.CombineShares(shares...)
+        if err != nil {return ElementError{Row: i / first.Cols, Col: i % first.Cols, Err: err}}
+        plain_vals[i] = decodeSigned(plain, n_to_s)
+        return
+    })
+    if err != nil {return Matrix{}, err}
+    return NewMatrix(first.Rows, first.Cols, plain_vals, Bigint{})
+}
+
+// decrypt the encrypted matrix cipher using at least threshold many key shares
+// the result is a plaintext Bigint matrix
+func DecryptMatrix(cipher Matrix, sks []*tcpaillier.KeyShare, opts ...Option) (Matrix, error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return Matrix{}, err}
+    if len(sks) < int(pk.K) {
+        return Matrix{}, fmt.Errorf("needed %d key shares to decrypt, but got %d", pk.K, len(sks))
+    }
+    // progress is reported over the partial decryptions and the combination
+    o, n, phases := newOptions(opts), len(cipher.values), int(pk.K) + 1
+    parts := make([]PartialDecryption, pk.K)
+    for i, sk := range sks[:pk.K] {
+        parts[i], err = PartialDecryptMatrix(cipher, sk, append(opts[:len(opts):len(opts)], withPhase(o, i, phases, n))...)
+        if err != nil {return Matrix{}, err}
+    }
+    return CombinePartialDecryptions(pk, parts, append(opts[:len(opts):len(opts)], withPhase(o, phases-1, phases, n))...)
+}
+
+// DecryptMatrix, stopping with ctx.Err() once ctx is done
+func DecryptMatrixContext(ctx context.Context, cipher Matrix, sks []*tcpaillier.KeyShare, opts ...Option) (Matrix, error) {
+    return DecryptMatrix(cipher, sks, withContext(ctx, opts)...)
+}
+
+// multiply every ciphertext of cipher by a fresh encryption of zero, so the result
+// decrypts to the same values but can't be linked to cipher
+// the elements are rerandomized in parallel, see WithWorkers
+func Rerandomize(cipher Matrix, opts ...Option) (Matrix, error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return Matrix{}, err}
+    b_vals := make([]interface{}, len(cipher.values))
+    err = parallelFor(len(b_vals), newOptions(opts), func(i int) error {
+        c, err := toBigint(cipher.values[i])
+        if err != nil {return elementError(cipher, i, err)}
+        r, err := pk.RandomModNToSPlusOneStar()
+        if err != nil {return elementError(cipher, i, err)}
+        b_vals[i], err = pk.ReRand(c, r)
+        if err != nil {return elementError(cipher, i, err)}
+        return nil
+    })
+    if err != nil {return Matrix{}, err}
+    return NewMatrix(cipher.Rows, cipher.Cols, b_vals, pk)
+}
+
+// Rerandomize, stopping with ctx.Err() once ctx is done
+func RerandomizeContext(ctx context.Context, cipher Matrix, opts ...Option) (Matrix, error) {
+    return Rerandomize(cipher, withContext(ctx, opts)...)
+}
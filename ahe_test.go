@@ -1,36 +1,21 @@
 package genmatrix
 
 import (
+    "errors"
     "math/big"
     "testing"
-    "github.com/niclabs/tcpaillier"
 )
 
-func EncryptMatrix(a Matrix, pk *tcpaillier.PubKey) (b Matrix, err error) {
-    b_vals := make([]interface{}, len(a.values))
-    for i := range a.values {
-        b_vals[i], _, err = pk.Encrypt(a.values[i].(*big.Int))
-        if err != nil {return}
+// small modulus for fast tests, never to be used outside of tests
+func insecureTestKey() DJOption {
+    return func(p *djParams) {
+        p.bits = 128
+        p.insecure = true
     }
-    return NewMatrix(a.Rows, a.Cols, b_vals, DJ_public_key{pk})
-}
-
-func DecryptMatrix(cipher Matrix, pk *tcpaillier.PubKey, sks []*tcpaillier.KeyShare) (plain Matrix, err error) {
-    plain_vals := make([]interface{}, len(cipher.values))
-    for i, enc_val := range cipher.values {
-        part_dec := make([]*tcpaillier.DecryptionShare, len(sks))
-        for j, sk := range sks {
-            part_dec[j], err = sk.PartialDecrypt(enc_val.(*big.Int))
-            if err != nil {return}
-        }
-        plain_vals[i], err = pk.CombineShares(part_dec...)
-        if err != nil {return}
-    }
-    return NewMatrix(cipher.Rows, cipher.Cols, plain_vals, Bigint{})
 }
 
 func TestEncryptedMatrixAddition(t *testing.T) {
-    cs, djsks, err := NewDJCryptosystem()
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
     if err != nil {t.Error(err)}
     a, err := NewMatrixFromInt(2, 3, []int{3, 4, 2, 1, 8, 5})
     if err != nil {t.Error(err)}
@@ -38,19 +23,19 @@ func TestEncryptedMatrixAddition(t *testing.T) {
     if err != nil {t.Error(err)}
     c, err := NewMatrixFromInt(2, 3, []int{4, 6, 5, 5, 13, 11})
     if err != nil {t.Error(err)}
-    a, err = EncryptMatrix(a, cs.PubKey)
+    a, err = EncryptMatrix(a, cs)
     if err != nil {t.Error(err)}
-    b, err = EncryptMatrix(b, cs.PubKey)
+    b, err = EncryptMatrix(b, cs)
     if err != nil {t.Error(err)}
     sum, err := a.Add(b)
     if err != nil {t.Error(err)}
-    sum, err = DecryptMatrix(sum, cs.PubKey, djsks)
+    sum, err = DecryptMatrix(sum, djsks)
     if err != nil {t.Error(err)}
     Compare(sum, c, t)
 }
 
 func TestEncryptedMatrixSubtraction(t *testing.T) {
-    cs, djsks, err := NewDJCryptosystem()
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
     if err != nil {t.Error(err)}
     a, err := NewMatrixFromInt(2, 3, []int{3, 4, 2, 1, 8, 5})
     if err != nil {t.Error(err)}
@@ -58,13 +43,13 @@ func TestEncryptedMatrixSubtraction(t *testing.T) {
     if err != nil {t.Error(err)}
     c, err := NewMatrixFromInt(2, 3, []int{2, 2, 0, 1, 4, 2})
     if err != nil {t.Error(err)}
-    a, err = EncryptMatrix(a, cs.PubKey)
+    a, err = EncryptMatrix(a, cs)
     if err != nil {t.Error(err)}
-    b, err = EncryptMatrix(b, cs.PubKey)
+    b, err = EncryptMatrix(b, cs)
     if err != nil {t.Error(err)}
     diff, err := a.Subtract(b)
     if err != nil {t.Error(err)}
-    diff, err = DecryptMatrix(diff, cs.PubKey, djsks)
+    diff, err = DecryptMatrix(diff, djsks)
     if err != nil {t.Error(err)}
     Compare(diff, c, t)
 }
@@ -74,16 +59,16 @@ func TestEncryptedMatrixMultiplication(t *testing.T) {
     if err != nil {t.Error(err)}
     b, err := NewMatrixFromInt(3, 2, []int{1,2,3,4,5,6})
     if err != nil {t.Error(err)}
-    cs, djsks, err := NewDJCryptosystem()
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
     if err != nil {t.Error(err)}
-    ae, err := EncryptMatrix(a, cs.PubKey)
+    ae, err := EncryptMatrix(a, cs)
     if err != nil {t.Error(err)}
     t.Run("plaintext from right", func(t *testing.T) {  
         ab, err := ae.Multiply(b)
         if err != nil {t.Error(err)}
         correct, err := a.Multiply(b)
         if err != nil {t.Error(err)}
-        ab, err = DecryptMatrix(ab, cs.PubKey, djsks)
+        ab, err = DecryptMatrix(ab, djsks)
         if err != nil {t.Error(err)}
         Compare(ab, correct, t)
     })
@@ -92,7 +77,7 @@ func TestEncryptedMatrixMultiplication(t *testing.T) {
         if err != nil {t.Error(err)}
         correct, err := b.Multiply(a)
         if err != nil {t.Error(err)}
-        ba, err = DecryptMatrix(ba, cs.PubKey, djsks)
+        ba, err = DecryptMatrix(ba, djsks)
         if err != nil {t.Error(err)}
         Compare(ba, correct, t)
     })
@@ -102,11 +87,264 @@ func TestMultiplyPlaintextFactor(t *testing.T) {
     a, err := NewMatrixFromInt(2, 3, []int{1,2,3,4,5,6})
     correct, err := NewMatrixFromInt(2, 3, []int{3,6,9,12,15,18})
     c := big.NewInt(3)
-    cs, djsks, err := NewDJCryptosystem()
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
     if err != nil {t.Error(err)}
-    a, err = EncryptMatrix(a, cs.PubKey)
+    a, err = EncryptMatrix(a, cs)
     a, err = a.Scale(c)
     if err != nil {t.Error(err)}
-    a, err = DecryptMatrix(a, cs.PubKey, djsks)
+    a, err = DecryptMatrix(a, djsks)
     Compare(a, correct, t)
-}
\ No newline at end of file
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(3, 2, []int{0, 1, 17, 4, 1000, 42})
+    if err != nil {t.Fatal(err)}
+    t.Run("vanilla", func(t *testing.T) {
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        if _, ok := ae.Space.(DJ_public_key); !ok {
+            t.Errorf("encrypted matrix in space %T", ae.Space)
+        }
+        ad, err := DecryptMatrix(ae, djsks)
+        if err != nil {t.Fatal(err)}
+        if _, ok := ad.Space.(Bigint); !ok {
+            t.Errorf("decrypted matrix in space %T", ad.Space)
+        }
+        Compare(ad, a, t)
+    })
+    t.Run("encrypt non-plaintext", func(t *testing.T) {
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        _, err = EncryptMatrix(ae, cs)
+        if !errors.Is(err, ErrNotPlaintext) {t.Errorf("expected ErrNotPlaintext, got %v", err)}
+    })
+    t.Run("decrypt plaintext", func(t *testing.T) {
+        _, err := DecryptMatrix(a, djsks)
+        if !errors.Is(err, ErrNotEncrypted) {t.Errorf("expected ErrNotEncrypted, got %v", err)}
+    })
+    t.Run("uninitialized element", func(t *testing.T) {
+        b, err := NewMatrix(2, 2, nil, Bigint{})
+        if err != nil {t.Fatal(err)}
+        _, err = EncryptMatrix(b, cs)
+        if !errors.Is(err, ErrElementType) {t.Errorf("expected ErrElementType, got %v", err)}
+        var elemErr ElementError
+        if !errors.As(err, &elemErr) {
+            t.Fatal("expected ElementError")
+        }
+        if elemErr.Row != 0 || elemErr.Col != 0 {
+            t.Errorf("wrong position (%d, %d)", elemErr.Row, elemErr.Col)
+        }
+    })
+    t.Run("too few key shares", func(t *testing.T) {
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        _, err = DecryptMatrix(ae, djsks[:1])
+        if err == nil {t.Error("no error on too few key shares")}
+    })
+    t.Run("foreign key shares", func(t *testing.T) {
+        _, other_sks, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        _, err = DecryptMatrix(ae, other_sks)
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+    })
+}
+
+func TestDJCryptosystemOptions(t *testing.T) {
+    t.Run("invalid parameters", func(t *testing.T) {
+        invalid := map[string][]DJOption{
+            "small modulus": {WithModulusBits(1024)},
+            "zero s": {insecureTestKey(), WithS(0)},
+            "single party": {insecureTestKey(), WithThreshold(1, 1)},
+            "threshold above parties": {insecureTestKey(), WithThreshold(4, 3)},
+            "threshold below majority": {insecureTestKey(), WithThreshold(2, 4)},
+        }
+        for name, opts := range invalid {
+            _, _, err := NewDJCryptosystem(opts...)
+            if err == nil {t.Errorf("%s: no error on invalid parameters", name)}
+        }
+    })
+    t.Run("threshold subset", func(t *testing.T) {
+        cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(2, 3))
+        if err != nil {t.Fatal(err)}
+        if len(djsks) != 3 {t.Fatalf("expected 3 key shares, got %d", len(djsks))}
+        a, err := NewMatrixFromInt(1, 3, []int{5, 0, 9})
+        if err != nil {t.Fatal(err)}
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        ad, err := DecryptMatrix(ae, djsks[1:])
+        if err != nil {t.Fatal(err)}
+        Compare(ad, a, t)
+    })
+    t.Run("s above 1", func(t *testing.T) {
+        // tcpaillier combines decryption shares modulo N only
+        _, _, err := NewDJCryptosystem(insecureTestKey(), WithS(2))
+        if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+    })
+}
+
+func TestPartialDecryption(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(3, 5))
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptMatrix(a, cs)
+    if err != nil {t.Fatal(err)}
+    parts := make([]PartialDecryption, len(djsks))
+    for i, sk := range djsks {
+        parts[i], err = PartialDecryptMatrix(ae, sk)
+        if err != nil {t.Fatal(err)}
+    }
+    t.Run("any threshold subset", func(t *testing.T) {
+        subsets := [][]PartialDecryption{
+            parts[:3],
+            parts[2:],
+            {parts[4], parts[0], parts[2]},
+        }
+        for _, subset := range subsets {
+            plain, err := CombinePartialDecryptions(cs, subset)
+            if err != nil {t.Fatal(err)}
+            Compare(plain, a, t)
+        }
+    })
+    t.Run("too few partial decryptions", func(t *testing.T) {
+        _, err := CombinePartialDecryptions(cs, parts[:2])
+        if err == nil {t.Error("no error on too few partial decryptions")}
+    })
+    t.Run("repeated partial decryption", func(t *testing.T) {
+        _, err := CombinePartialDecryptions(cs, []PartialDecryption{parts[0], parts[1], parts[0]})
+        if err == nil {t.Error("no error on repeated partial decryption")}
+    })
+    t.Run("repeated before enough distinct", func(t *testing.T) {
+        plain, err := CombinePartialDecryptions(cs, []PartialDecryption{parts[0], parts[0], parts[1], parts[3]})
+        if err != nil {t.Fatal(err)}
+        Compare(plain, a, t)
+    })
+    t.Run("foreign partial decryption", func(t *testing.T) {
+        other_cs, other_sks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(3, 5))
+        if err != nil {t.Fatal(err)}
+        be, err := EncryptMatrix(a, other_cs)
+        if err != nil {t.Fatal(err)}
+        other, err := PartialDecryptMatrix(be, other_sks[4])
+        if err != nil {t.Fatal(err)}
+        // the foreign part comes after threshold many valid ones
+        _, err = CombinePartialDecryptions(cs, []PartialDecryption{parts[0], parts[1], parts[2], other})
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+    })
+    t.Run("different ciphertexts", func(t *testing.T) {
+        be, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        other, err := PartialDecryptMatrix(be, djsks[2])
+        if err != nil {t.Fatal(err)}
+        _, err = CombinePartialDecryptions(cs, []PartialDecryption{parts[0], parts[1], other})
+        if err == nil {t.Error("no error on partial decryptions of different ciphertexts")}
+    })
+    t.Run("different dimensions", func(t *testing.T) {
+        b, err := NewMatrixFromInt(1, 4, []int{1, 2, 3, 4})
+        if err != nil {t.Fatal(err)}
+        be, err := EncryptMatrix(b, cs)
+        if err != nil {t.Fatal(err)}
+        other, err := PartialDecryptMatrix(be, djsks[2])
+        if err != nil {t.Fatal(err)}
+        _, err = CombinePartialDecryptions(cs, []PartialDecryption{parts[0], parts[1], other})
+        if err == nil {t.Error("no error on partial decryptions of different dimensions")}
+    })
+    t.Run("foreign key share", func(t *testing.T) {
+        _, other_sks, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        _, err = PartialDecryptMatrix(ae, other_sks[0])
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+    })
+}
+
+func TestSignedEncoding(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    t.Run("negative results", func(t *testing.T) {
+        a, err := NewMatrixFromInt(1, 3, []int{2, -7, 0})
+        if err != nil {t.Fatal(err)}
+        b, err := NewMatrixFromInt(1, 3, []int{4, -3, 5})
+        if err != nil {t.Fatal(err)}
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        be, err := EncryptMatrix(b, cs)
+        if err != nil {t.Fatal(err)}
+        diff, err := ae.Subtract(be)
+        if err != nil {t.Fatal(err)}
+        diff, err = diff.Scale(big.NewInt(-3))
+        if err != nil {t.Fatal(err)}
+        diff, err = DecryptMatrix(diff, djsks)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(1, 3, []int{6, 12, 15})
+        if err != nil {t.Fatal(err)}
+        Compare(diff, correct, t)
+    })
+    t.Run("range", func(t *testing.T) {
+        max := MaxSigned(cs)
+        for _, x := range []*big.Int{max, new(big.Int).Neg(max), big.NewInt(-1), big.NewInt(0)} {
+            enc, err := EncodeSigned(cs, x)
+            if err != nil {t.Fatal(err)}
+            if enc.Sign() < 0 || enc.Cmp(cs.Cache().NToS) >= 0 {
+                t.Errorf("%v encoded outside the plaintext space", x)
+            }
+            if dec := DecodeSigned(cs, enc); dec.Cmp(x) != 0 {
+                t.Errorf("expected %v, got %v", x, dec)
+            }
+        }
+        a, err := NewMatrix(1, 2, []interface{}{max, new(big.Int).Neg(max)}, Bigint{})
+        if err != nil {t.Fatal(err)}
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        ad, err := DecryptMatrix(ae, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(ad, a, t)
+    })
+    t.Run("overflow", func(t *testing.T) {
+        over := new(big.Int).Add(MaxSigned(cs), big.NewInt(1))
+        for _, x := range []*big.Int{over, new(big.Int).Neg(over)} {
+            _, err := EncodeSigned(cs, x)
+            if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+            a, err := NewMatrix(1, 1, []interface{}{x}, Bigint{})
+            if err != nil {t.Fatal(err)}
+            _, err = EncryptMatrix(a, cs)
+            if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+            _, _, err = EncryptMatrixWithProof(a, cs)
+            if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+        }
+    })
+}
+
+func TestRerandomize(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 3, []int{3, -4, 0, 1, 8, 5})
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptMatrix(a, cs)
+    if err != nil {t.Fatal(err)}
+    be, err := Rerandomize(ae, WithWorkers(2))
+    if err != nil {t.Fatal(err)}
+    for i := range ae.values {
+        if ae.values[i].(*big.Int).Cmp(be.values[i].(*big.Int)) == 0 {
+            t.Errorf("ciphertext %d unchanged", i)
+        }
+    }
+    b, err := DecryptMatrix(be, djsks)
+    if err != nil {t.Fatal(err)}
+    Compare(b, a, t)
+    t.Run("plaintext", func(t *testing.T) {
+        _, err := Rerandomize(a)
+        if !errors.Is(err, ErrNotEncrypted) {t.Errorf("expected ErrNotEncrypted, got %v", err)}
+    })
+    t.Run("packed", func(t *testing.T) {
+        ap, err := PackMatrix(a, cs, 8)
+        if err != nil {t.Fatal(err)}
+        bp, err := ap.Rerandomize()
+        if err != nil {t.Fatal(err)}
+        b, err := DecryptPacked(bp, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(b, a, t)
+    })
+}
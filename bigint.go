@@ -46,6 +46,45 @@ func (p Bigint) Scalarspace() bool {
     return true
 }
 
+// exact division, fails if b does not divide a
+func (p Bigint) Divide(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    if b.(*big.Int).Sign() == 0 {
+        return nil, fmt.Errorf("division by zero")
+    }
+    q, r := new(big.Int).QuoRem(a.(*big.Int), b.(*big.Int), new(big.Int))
+    if r.Sign() != 0 {
+        return nil, fmt.Errorf("%v does not divide %v", b, a)
+    }
+    return q, nil
+}
+
+func (p Bigint) IsZero(a interface{}) bool {
+    v, ok := a.(*big.Int)
+    return ok && v != nil && v.Sign() == 0
+}
+
+func (p Bigint) Zero() interface{} {
+    return new(big.Int)
+}
+
+func (p Bigint) One() interface{} {
+    return big.NewInt(1)
+}
+
+func (p Bigint) Negate(a interface{}) (interface{}, error) {
+    v, err := toBigint(a)
+    if err != nil {return nil, err}
+    return new(big.Int).Neg(v), nil
+}
+
+func (p Bigint) Equal(a, b interface{}) (bool, error) {
+    err := assertBigint(a, b)
+    if err != nil {return false, err}
+    return a.(*big.Int).Cmp(b.(*big.Int)) == 0, nil
+}
+
 // create a new Matrix from int values
 func NewMatrixFromInt(rows, cols int, data []int) (Matrix, error) {
     if data == nil {
@@ -1,6 +1,7 @@
 package genmatrix
 
 import (
+    "errors"
     "testing"
     "math/big"
 )
@@ -257,10 +258,20 @@ func TestConcatenation(t *testing.T) {
 func TestCrop(t *testing.T) {
     a, err := NewMatrixFromInt(3, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9})
     if err != nil {t.Error(err)}
-    a = a.CropHorizontally(2)   
+    a, err = a.CropHorizontally(2)
+    if err != nil {t.Fatal(err)}
     correct, err := NewMatrixFromInt(3, 2, []int{2, 3, 5, 6, 8, 9})
     if err != nil {t.Error(err)}
     Compare(a, correct, t)
+    for _, k := range []int{-1, 3} {
+        _, err = a.CropHorizontally(k)
+        if err == nil {t.Errorf("no error on keeping %d of 2 columns", k)}
+    }
+    empty, err := a.CropHorizontally(0)
+    if err != nil {t.Fatal(err)}
+    if empty.Rows != 3 || empty.Cols != 0 {
+        t.Errorf("expected 3 x 0 matrix, got %d x %d", empty.Rows, empty.Cols)
+    }
 }
 
 func TestMod(t *testing.T) {
@@ -282,3 +293,140 @@ func TestMod(t *testing.T) {
         }
     }
 }
+
+func TestTranspose(t *testing.T) {
+    a, err := NewMatrixFromInt(2, 3, []int{1, 2, 3, 4, 5, 6})
+    if err != nil {t.Error(err)}
+    correct, err := NewMatrixFromInt(3, 2, []int{1, 4, 2, 5, 3, 6})
+    if err != nil {t.Error(err)}
+    Compare(a.Transpose(), correct, t)
+}
+
+func TestVerticalConcatenation(t *testing.T) {
+    a, err := NewMatrixFromInt(1, 2, []int{1, 2})
+    if err != nil {t.Error(err)}
+    t.Run("valid concatenation", func(t *testing.T) {
+        b, err := NewMatrixFromInt(2, 2, []int{3, 4, 5, 6})
+        if err != nil {t.Error(err)}
+        correct, err := NewMatrixFromInt(3, 2, []int{1, 2, 3, 4, 5, 6})
+        if err != nil {t.Error(err)}
+        ab, err := a.ConcatenateVertically(b)
+        if err != nil {t.Error(err)}
+        Compare(ab, correct, t)
+    })
+    t.Run("invalid concatenation", func(t *testing.T) {
+        b, err := NewMatrix(1, 3, nil, Bigint{})
+        if err != nil {t.Error(err)}
+        _, err = a.ConcatenateVertically(b)
+        if err == nil {t.Error("no error on mismatched dimensions")}
+    })
+}
+
+func TestSubmatrix(t *testing.T) {
+    a, err := NewMatrixFromInt(3, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9})
+    if err != nil {t.Error(err)}
+    t.Run("range", func(t *testing.T) {
+        b, err := a.Submatrix(1, 3, 0, 2)
+        if err != nil {t.Error(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{4, 5, 7, 8})
+        if err != nil {t.Error(err)}
+        Compare(b, correct, t)
+        _, err = a.Submatrix(0, 4, 0, 1)
+        if err == nil {t.Error("no error on index out of bounds")}
+        _, err = a.Submatrix(0, 1, 2, 1)
+        if err == nil {t.Error("no error on reversed range")}
+    })
+    t.Run("select rows", func(t *testing.T) {
+        b, err := a.SelectRows([]int{2, 0})
+        if err != nil {t.Error(err)}
+        correct, err := NewMatrixFromInt(2, 3, []int{7, 8, 9, 1, 2, 3})
+        if err != nil {t.Error(err)}
+        Compare(b, correct, t)
+        _, err = a.SelectRows([]int{3})
+        if err == nil {t.Error("no error on index out of bounds")}
+    })
+    t.Run("select columns", func(t *testing.T) {
+        b, err := a.SelectCols([]int{1, 1})
+        if err != nil {t.Error(err)}
+        correct, err := NewMatrixFromInt(3, 2, []int{2, 2, 5, 5, 8, 8})
+        if err != nil {t.Error(err)}
+        Compare(b, correct, t)
+        _, err = a.SelectCols([]int{-1})
+        if err == nil {t.Error("no error on index out of bounds")}
+    })
+    t.Run("remove row and column", func(t *testing.T) {
+        b, err := a.RemoveRow(1)
+        if err != nil {t.Error(err)}
+        b, err = b.RemoveCol(0)
+        if err != nil {t.Error(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{2, 3, 8, 9})
+        if err != nil {t.Error(err)}
+        Compare(b, correct, t)
+        _, err = a.RemoveRow(3)
+        if err == nil {t.Error("no error on index out of bounds")}
+        _, err = a.RemoveCol(3)
+        if err == nil {t.Error("no error on index out of bounds")}
+    })
+}
+
+func TestCapabilities(t *testing.T) {
+    t.Run("zeros", func(t *testing.T) {
+        a, err := Zeros(2, 3, Bigint{})
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(2, 3, []int{0, 0, 0, 0, 0, 0})
+        if err != nil {t.Fatal(err)}
+        Compare(a, correct, t)
+    })
+    t.Run("identity", func(t *testing.T) {
+        a, err := Identity(3, Bigint{})
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(3, 3, []int{1, 0, 0, 0, 1, 0, 0, 0, 1})
+        if err != nil {t.Fatal(err)}
+        Compare(a, correct, t)
+    })
+    t.Run("equal", func(t *testing.T) {
+        a, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+        if err != nil {t.Fatal(err)}
+        b, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+        if err != nil {t.Fatal(err)}
+        c, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 5})
+        if err != nil {t.Fatal(err)}
+        d, err := NewMatrixFromInt(1, 4, []int{1, 2, 3, 4})
+        if err != nil {t.Fatal(err)}
+        for _, tc := range []struct{m Matrix; eq bool}{{b, true}, {c, false}, {d, false}} {
+            eq, err := a.Equal(tc.m)
+            if err != nil {t.Fatal(err)}
+            if eq != tc.eq {t.Errorf("expected %t, got %t", tc.eq, eq)}
+        }
+    })
+    t.Run("negate", func(t *testing.T) {
+        a, err := NewMatrixFromInt(1, 3, []int{1, -2, 0})
+        if err != nil {t.Fatal(err)}
+        a, err = a.Negate()
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(1, 3, []int{-1, 2, 0})
+        if err != nil {t.Fatal(err)}
+        Compare(a, correct, t)
+    })
+    t.Run("empty inner dimension", func(t *testing.T) {
+        a, err := NewMatrix(2, 0, nil, Bigint{})
+        if err != nil {t.Fatal(err)}
+        b, err := NewMatrix(0, 2, nil, Bigint{})
+        if err != nil {t.Fatal(err)}
+        c, err := a.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        correct, err := Zeros(2, 2, Bigint{})
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+    t.Run("missing capability", func(t *testing.T) {
+        cs, _, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        _, err = Identity(2, cs)
+        if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+        a, err := Zeros(2, 2, cs)
+        if err != nil {t.Fatal(err)}
+        _, err = a.Equal(a)
+        if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+    })
+}
@@ -4,6 +4,7 @@ import (
     "math/big"
     "github.com/niclabs/tcpaillier"
     "errors"
+    "fmt"
 )
 
 type DJ_public_key struct {
@@ -34,16 +35,87 @@ func (pk DJ_public_key) Scale(ciphertext, factor interface{}) (product interface
 func (pk DJ_public_key) Multiply(a, b interface{}) (interface{}, error) {
     err := assertBigint(a, b)
     if err != nil {return nil, err}
-    return nil, errors.New("multiplication not supported")
+    return nil, errors.New("multiplication not supported, use MultiplyEncrypted")
 }
 
 func (pk DJ_public_key) Scalarspace() bool {
     return false
 }
 
-func NewDJCryptosystem() (public_key DJ_public_key, secret_keys []*tcpaillier.KeyShare, err error) {
-    secret_keys, djpk, err := tcpaillier.NewKey(128, 1, 3, 3)
+// the trivial encryption of 0, without randomness
+func (pk DJ_public_key) Zero() interface{} {
+    return big.NewInt(1)
+}
+
+// encryption of the negated plaintext
+func (pk DJ_public_key) Negate(a interface{}) (interface{}, error) {
+    c, err := toBigint(a)
+    if err != nil {return nil, err}
+    neg, _, err := pk.PubKey.Multiply(c, big.NewInt(-1))
+    return neg, err
+}
+
+// smallest modulus size accepted by NewDJCryptosystem
+const MinModulusBits = 2048
+
+type djParams struct {
+    bits int
+    s, threshold, parties uint8
+    insecure bool
+}
+
+// option for NewDJCryptosystem
+type DJOption func(*djParams)
+
+// set the bit size of the modulus N
+func WithModulusBits(bits int) DJOption {
+    return func(p *djParams) {p.bits = bits}
+}
+
+// set the Damgård-Jurik parameter s, giving plaintexts in Z_{N^s}
+// only s = 1 is supported, as tcpaillier decrypts modulo N
+func WithS(s uint8) DJOption {
+    return func(p *djParams) {p.s = s}
+}
+
+// let threshold out of parties key shares be needed for decryption
+func WithThreshold(threshold, parties uint8) DJOption {
+    return func(p *djParams) {
+        p.threshold = threshold
+        p.parties = parties
+    }
+}
+
+func (p djParams) validate() error {
+    if p.bits < MinModulusBits && !(p.insecure && p.bits >= 64) {
+        return fmt.Errorf("modulus must be at least %d bits, but is %d", MinModulusBits, p.bits)
+    }
+    // tcpaillier only recovers plaintexts modulo N when combining decryption shares
+    if p.s != 1 {
+        return fmt.Errorf("%w: s must be 1, but is %d", ErrNotSupported, p.s)
+    }
+    if p.parties < 2 {
+        return fmt.Errorf("there must be at least 2 parties, but there are %d", p.parties)
+    }
+    if p.threshold < p.parties/2+1 || p.threshold > p.parties {
+        return fmt.Errorf("threshold must be between %d and %d for %d parties, but is %d", p.parties/2+1, p.parties, p.parties, p.threshold)
+    }
+    return nil
+}
+
+// create a threshold Damgård-Jurik cryptosystem
+// defaults to a MinModulusBits modulus, s = 1 and 3 out of 3 key shares
+func NewDJCryptosystem(opts ...DJOption) (public_key DJ_public_key, secret_keys []*tcpaillier.KeyShare, err error) {
+    params := djParams{bits: MinModulusBits, s: 1, threshold: 3, parties: 3}
+    for _, opt := range opts {
+        opt(&params)
+    }
+    err = params.validate()
     if err != nil {return}
+    secret_keys, djpk, err := tcpaillier.NewKey(params.bits, params.s, params.parties, params.threshold)
+    if err != nil {return}
+    // fill the cache up front, as it is not safe for concurrent initialization
+    djpk.Cache()
     public_key = DJ_public_key{djpk}
     return
-}
\ No newline at end of file
+}
@@ -0,0 +1,269 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+)
+
+// the space of a matrix does not support the division needed by an operation
+var ErrNotField = errors.New("space does not support division")
+
+// assert that the space of a is a Field
+func fieldOf(a Matrix) (Field, error) {
+    f, ok := a.Space.(Field)
+    if !ok {
+        return nil, fmt.Errorf("%w: space is %T", ErrNotField, a.Space)
+    }
+    return f, nil
+}
+
+// Gaussian elimination on a copy of the values of a
+// with reduced set, pivots are scaled to one and eliminated above as well as below
+// returns the values, the pivot column of each non-zero row and the number of row swaps
+func rowReduce(a Matrix, f Field, reduced bool) (vals []interface{}, pivots []int, swaps int, err error) {
+    vals = make([]interface{}, len(a.values))
+    copy(vals, a.values)
+    row := func(i int) []interface{} {return vals[i*a.Cols:(i+1)*a.Cols]}
+    // row i -= factor * row r, starting from column col
+    subtractRow := func(i, r, col int, factor interface{}) error {
+        for j := col; j < a.Cols; j += 1 {
+            p, err := f.Multiply(factor, vals[r*a.Cols+j])
+            if err != nil {return err}
+            vals[i*a.Cols+j], err = f.Subtract(vals[i*a.Cols+j], p)
+            if err != nil {return err}
+        }
+        return nil
+    }
+    r := 0
+    for col := 0; col < a.Cols && r < a.Rows; col += 1 {
+        pivot := -1
+        for i := r; i < a.Rows; i += 1 {
+            if !f.IsZero(vals[i*a.Cols+col]) {
+                pivot = i
+                break
+            }
+        }
+        if pivot == -1 {
+            continue
+        }
+        if pivot != r {
+            tmp := make([]interface{}, a.Cols)
+            copy(tmp, row(pivot))
+            copy(row(pivot), row(r))
+            copy(row(r), tmp)
+            swaps += 1
+        }
+        inv, err := f.Inverse(vals[r*a.Cols+col])
+        if err != nil {return nil, nil, 0, err}
+        if reduced {
+            for j := col; j < a.Cols; j += 1 {
+                vals[r*a.Cols+j], err = f.Multiply(vals[r*a.Cols+j], inv)
+                if err != nil {return nil, nil, 0, err}
+            }
+        }
+        for i := 0; i < a.Rows; i += 1 {
+            if i == r || (i < r && !reduced) || f.IsZero(vals[i*a.Cols+col]) {
+                continue
+            }
+            factor := vals[i*a.Cols+col]
+            if !reduced {
+                factor, err = f.Multiply(factor, inv)
+                if err != nil {return nil, nil, 0, err}
+            }
+            err = subtractRow(i, r, col, factor)
+            if err != nil {return nil, nil, 0, err}
+        }
+        pivots = append(pivots, col)
+        r += 1
+    }
+    return
+}
+
+// row echelon form of a, and the pivot column of each non-zero row
+// requires the space of a to be a Field
+func (a Matrix) RowEchelon() (Matrix, []int, error) {
+    return a.echelon(false)
+}
+
+// reduced row echelon form of a, and the pivot column of each non-zero row
+// requires the space of a to be a Field
+func (a Matrix) ReducedRowEchelon() (Matrix, []int, error) {
+    return a.echelon(true)
+}
+
+func (a Matrix) echelon(reduced bool) (Matrix, []int, error) {
+    f, err := fieldOf(a)
+    if err != nil {return Matrix{}, nil, err}
+    vals, pivots, _, err := rowReduce(a, f, reduced)
+    if err != nil {return Matrix{}, nil, err}
+    b, err := NewMatrix(a.Rows, a.Cols, vals, a.Space)
+    return b, pivots, err
+}
+
+// rank of a, requires the space of a to be a Field
+func (a Matrix) Rank() (int, error) {
+    _, pivots, err := a.RowEchelon()
+    return len(pivots), err
+}
+
+// the matrix has no inverse
+var ErrSingular = errors.New("singular matrix")
+
+// zero of the space of a, computed as x - x for the first element x of a
+// if the space is not a Zeroer
+func zeroOf(a Matrix) (interface{}, error) {
+    if z, ok := a.Space.(Zeroer); ok {
+        return z.Zero(), nil
+    }
+    return a.Space.Subtract(a.values[0], a.values[0])
+}
+
+// -x in space, computed as (x - x) - x if the space is not a Negator
+func negate(space Space, x interface{}) (interface{}, error) {
+    if n, ok := space.(Negator); ok {
+        return n.Negate(x)
+    }
+    zero, err := space.Subtract(x, x)
+    if err != nil {return nil, err}
+    return space.Subtract(zero, x)
+}
+
+// determinant of the square matrix a
+// uses Gaussian elimination if the space of a is a Field, and fraction-free
+// (Bareiss) elimination if it is an ExactDivider, such as Bigint
+func (a Matrix) Determinant() (interface{}, error) {
+    if a.Rows != a.Cols {
+        return nil, fmt.Errorf("determinant of non-square matrix: %d x %d", a.Rows, a.Cols)
+    }
+    if a.Rows == 0 {
+        return nil, fmt.Errorf("determinant of empty matrix")
+    }
+    if f, ok := a.Space.(Field); ok {
+        return fieldDeterminant(a, f)
+    }
+    if d, ok := a.Space.(ExactDivider); ok {
+        return bareissDeterminant(a, d)
+    }
+    return nil, fmt.Errorf("%w: space is %T", ErrNotField, a.Space)
+}
+
+// product of the pivots of the row echelon form, negated for an odd number of row swaps
+func fieldDeterminant(a Matrix, f Field) (interface{}, error) {
+    vals, pivots, swaps, err := rowReduce(a, f, false)
+    if err != nil {return nil, err}
+    if len(pivots) < a.Rows {
+        return zeroOf(a)
+    }
+    det := vals[0]
+    for i := 1; i < a.Rows; i += 1 {
+        det, err = f.Multiply(det, vals[i*a.Cols+i])
+        if err != nil {return nil, err}
+    }
+    if swaps % 2 == 1 {
+        return negate(f, det)
+    }
+    return det, nil
+}
+
+// fraction-free elimination, where every division is exact
+func bareissDeterminant(a Matrix, d ExactDivider) (interface{}, error) {
+    n := a.Rows
+    m := make([]interface{}, len(a.values))
+    copy(m, a.values)
+    negative := false
+    var prev interface{}
+    for k := 0; k < n-1; k += 1 {
+        if d.IsZero(m[k*n+k]) {
+            pivot := -1
+            for i := k + 1; i < n; i += 1 {
+                if !d.IsZero(m[i*n+k]) {
+                    pivot = i
+                    break
+                }
+            }
+            if pivot == -1 {
+                return zeroOf(a)
+            }
+            for j := 0; j < n; j += 1 {
+                m[k*n+j], m[pivot*n+j] = m[pivot*n+j], m[k*n+j]
+            }
+            negative = !negative
+        }
+        for i := k + 1; i < n; i += 1 {
+            for j := k + 1; j < n; j += 1 {
+                p, err := d.Multiply(m[i*n+j], m[k*n+k])
+                if err != nil {return nil, err}
+                q, err := d.Multiply(m[i*n+k], m[k*n+j])
+                if err != nil {return nil, err}
+                m[i*n+j], err = d.Subtract(p, q)
+                if err != nil {return nil, err}
+                if prev != nil {
+                    m[i*n+j], err = d.Divide(m[i*n+j], prev)
+                    if err != nil {return nil, err}
+                }
+            }
+        }
+        prev = m[k*n+k]
+    }
+    if negative {
+        return negate(d, m[n*n-1])
+    }
+    return m[n*n-1], nil
+}
+
+// inverse of the square matrix a, by elimination on [a|I]
+// requires the space of a to be a Field, fails with ErrSingular if a has no inverse
+func (a Matrix) Inverse() (Matrix, error) {
+    f, err := fieldOf(a)
+    if err != nil {return Matrix{}, err}
+    if a.Rows != a.Cols {
+        return Matrix{}, fmt.Errorf("inverse of non-square matrix: %d x %d", a.Rows, a.Cols)
+    }
+    n := a.Rows
+    id, err := identityLike(a, f)
+    if err != nil {return Matrix{}, err}
+    augmented, err := a.Concatenate(id)
+    if err != nil {return Matrix{}, err}
+    reduced, pivots, err := augmented.ReducedRowEchelon()
+    if err != nil {return Matrix{}, err}
+    if len(pivots) < n || (n > 0 && pivots[n-1] != n-1) {
+        return Matrix{}, ErrSingular
+    }
+    return reduced.CropHorizontally(n)
+}
+
+// identity matrix of the size of the square matrix a, in the space of a
+// without Oner and Zeroer, one is found as x * x^-1 for a non-zero element x
+// of a, and a has no inverse if there is none
+func identityLike(a Matrix, f Field) (Matrix, error) {
+    n := a.Rows
+    _, has_one := f.(Oner)
+    _, has_zero := f.(Zeroer)
+    if has_one && has_zero {
+        return Identity(n, f)
+    }
+    var one, zero interface{}
+    for _, v := range a.values {
+        if !f.IsZero(v) {
+            inv, err := f.Inverse(v)
+            if err != nil {return Matrix{}, err}
+            one, err = f.Multiply(v, inv)
+            if err != nil {return Matrix{}, err}
+            zero, err = f.Subtract(v, v)
+            if err != nil {return Matrix{}, err}
+            break
+        }
+    }
+    if one == nil {
+        return Matrix{}, ErrSingular
+    }
+    vals := make([]interface{}, n*n)
+    for i := range vals {
+        if i / n == i % n {
+            vals[i] = one
+        } else {
+            vals[i] = zero
+        }
+    }
+    return NewMatrix(n, n, vals, f)
+}
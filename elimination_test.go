@@ -0,0 +1,152 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestRowEchelon(t *testing.T) {
+    z, err := NewZn(big.NewInt(7))
+    if err != nil {t.Fatal(err)}
+    // second row is twice the first modulo 7
+    a, err := z.NewMatrixFromInt(3, 4, []int{1, 2, 3, 4, 2, 4, 6, 1, 0, 1, 1, 5})
+    if err != nil {t.Fatal(err)}
+    t.Run("reduced", func(t *testing.T) {
+        b, pivots, err := a.ReducedRowEchelon()
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(3, 4, []int{1, 0, 1, 1, 0, 1, 1, 5, 0, 0, 0, 0})
+        if err != nil {t.Fatal(err)}
+        Compare(b, correct, t)
+        if len(pivots) != 2 || pivots[0] != 0 || pivots[1] != 1 {
+            t.Errorf("expected pivots [0 1], got %v", pivots)
+        }
+    })
+    t.Run("not reduced", func(t *testing.T) {
+        b, pivots, err := a.RowEchelon()
+        if err != nil {t.Fatal(err)}
+        if len(pivots) != 2 || pivots[0] != 0 || pivots[1] != 1 {
+            t.Errorf("expected pivots [0 1], got %v", pivots)
+        }
+        for i, col := range pivots {
+            for k := i + 1; k < b.Rows; k += 1 {
+                v, err := b.At(k, col)
+                if err != nil {t.Fatal(err)}
+                if !z.IsZero(v) {
+                    t.Errorf("non-zero value below pivot at (%d, %d)", k, col)
+                }
+            }
+        }
+        orig, err := decode(a.At(1, 0))
+        if err != nil {t.Fatal(err)}
+        if orig.Int64() != 2 {t.Error("elimination modified the input matrix")}
+    })
+    t.Run("rank", func(t *testing.T) {
+        rank, err := a.Rank()
+        if err != nil {t.Fatal(err)}
+        if rank != 2 {t.Errorf("expected rank 2, got %d", rank)}
+        zero, err := z.NewMatrixFromInt(2, 2, []int{0, 0, 0, 0})
+        if err != nil {t.Fatal(err)}
+        rank, err = zero.Rank()
+        if err != nil {t.Fatal(err)}
+        if rank != 0 {t.Errorf("expected rank 0, got %d", rank)}
+    })
+    t.Run("not a field", func(t *testing.T) {
+        b, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+        if err != nil {t.Fatal(err)}
+        _, err = b.Rank()
+        if !errors.Is(err, ErrNotField) {t.Errorf("expected ErrNotField, got %v", err)}
+    })
+    t.Run("non-invertible pivot", func(t *testing.T) {
+        z6, err := NewZn(big.NewInt(6))
+        if err != nil {t.Fatal(err)}
+        b, err := z6.NewMatrixFromInt(2, 2, []int{2, 1, 1, 1})
+        if err != nil {t.Fatal(err)}
+        _, _, err = b.ReducedRowEchelon()
+        if !errors.Is(err, ErrNotInvertible) {t.Errorf("expected ErrNotInvertible, got %v", err)}
+    })
+}
+
+func TestDeterminant(t *testing.T) {
+    t.Run("bareiss", func(t *testing.T) {
+        cases := []struct {
+            n int
+            data []int
+            det int64
+        }{
+            {1, []int{-3}, -3},
+            {2, []int{0, 1, 1, 0}, -1},
+            {3, []int{2, -1, 0, -1, 2, -1, 0, -1, 2}, 4},
+            {3, []int{1, 2, 3, 2, 4, 6, 1, 1, 1}, 0},
+            {4, []int{0, 3, 1, -2, 4, 0, 5, 1, -1, 2, 0, 7, 3, -3, 2, 0}, -58},
+        }
+        for _, c := range cases {
+            a, err := NewMatrixFromInt(c.n, c.n, c.data)
+            if err != nil {t.Fatal(err)}
+            det, err := a.Determinant()
+            if err != nil {t.Fatal(err)}
+            if det.(*big.Int).Int64() != c.det {
+                t.Errorf("expected determinant %d, got %v", c.det, det)
+            }
+        }
+    })
+    t.Run("field", func(t *testing.T) {
+        z, err := NewZn(big.NewInt(7))
+        if err != nil {t.Fatal(err)}
+        a, err := z.NewMatrixFromInt(3, 3, []int{3, 1, 4, 1, 5, 9, 2, 6, 5})
+        if err != nil {t.Fatal(err)}
+        det, err := a.Determinant()
+        if err != nil {t.Fatal(err)}
+        if det.(*big.Int).Int64() != 1 {
+            t.Errorf("expected determinant 1, got %v", det)
+        }
+        b, err := z.NewMatrixFromInt(2, 2, []int{0, 1, 1, 0})
+        if err != nil {t.Fatal(err)}
+        det, err = b.Determinant()
+        if err != nil {t.Fatal(err)}
+        if det.(*big.Int).Int64() != 6 {
+            t.Errorf("expected determinant 6, got %v", det)
+        }
+    })
+    t.Run("non-square", func(t *testing.T) {
+        a, err := NewMatrixFromInt(2, 3, []int{1, 2, 3, 4, 5, 6})
+        if err != nil {t.Fatal(err)}
+        _, err = a.Determinant()
+        if err == nil {t.Error("no error on non-square matrix")}
+    })
+}
+
+func TestInverse(t *testing.T) {
+    z, err := NewZn(big.NewInt(7))
+    if err != nil {t.Fatal(err)}
+    t.Run("vanilla", func(t *testing.T) {
+        a, err := z.NewMatrixFromInt(3, 3, []int{3, 1, 4, 1, 5, 9, 2, 6, 5})
+        if err != nil {t.Fatal(err)}
+        inv, err := a.Inverse()
+        if err != nil {t.Fatal(err)}
+        id, err := NewMatrixFromInt(3, 3, []int{1, 0, 0, 0, 1, 0, 0, 0, 1})
+        if err != nil {t.Fatal(err)}
+        prod, err := a.Multiply(inv)
+        if err != nil {t.Fatal(err)}
+        Compare(prod, id, t)
+        prod, err = inv.Multiply(a)
+        if err != nil {t.Fatal(err)}
+        Compare(prod, id, t)
+    })
+    t.Run("singular", func(t *testing.T) {
+        a, err := z.NewMatrixFromInt(2, 2, []int{1, 2, 2, 4})
+        if err != nil {t.Fatal(err)}
+        _, err = a.Inverse()
+        if !errors.Is(err, ErrSingular) {t.Errorf("expected ErrSingular, got %v", err)}
+        zero, err := z.NewMatrixFromInt(2, 2, []int{0, 0, 0, 0})
+        if err != nil {t.Fatal(err)}
+        _, err = zero.Inverse()
+        if !errors.Is(err, ErrSingular) {t.Errorf("expected ErrSingular, got %v", err)}
+    })
+    t.Run("not a field", func(t *testing.T) {
+        a, err := NewMatrixFromInt(2, 2, []int{1, 0, 0, 1})
+        if err != nil {t.Fatal(err)}
+        _, err = a.Inverse()
+        if !errors.Is(err, ErrNotField) {t.Errorf("expected ErrNotField, got %v", err)}
+    })
+}
@@ -0,0 +1,125 @@
+package genmatrix
+
+import (
+    "crypto/rand"
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// DJ_public_key with deterministic scaling and subtraction, for results that
+// all parties of a protocol must compute identically from public values
+type publicDJ struct {
+    DJ_public_key
+}
+
+func (pk publicDJ) Subtract(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    neg, err := pk.PubKey.MultiplyFixed(b.(*big.Int), big.NewInt(-1), big.NewInt(1))
+    if err != nil {return nil, err}
+    return pk.PubKey.Add(a.(*big.Int), neg)
+}
+
+func (pk publicDJ) Scale(ciphertext, factor interface{}) (interface{}, error) {
+    err := assertBigint(ciphertext, factor)
+    if err != nil {return nil, err}
+    return pk.PubKey.MultiplyFixed(ciphertext.(*big.Int), factor.(*big.Int), big.NewInt(1))
+}
+
+// assert that msg is an encrypted matrix of the given size under pk
+func receivedCiphertext(msg interface{}, from int, pk DJ_public_key, rows, cols int) (Matrix, error) {
+    m, ok := msg.(Matrix)
+    if !ok {
+        return Matrix{}, fmt.Errorf("party %d sent %T, expected Matrix", from, msg)
+    }
+    m_pk, err := encryptionKey(m)
+    if err != nil {return Matrix{}, fmt.Errorf("party %d: %w", from, err)}
+    if !samePubKey(m_pk.PubKey, pk.PubKey) {
+        return Matrix{}, fmt.Errorf("party %d: %w", from, ErrKeyMismatch)
+    }
+    if m.Rows != rows || m.Cols != cols {
+        return Matrix{}, fmt.Errorf("party %d sent a %d x %d matrix, expected %d x %d", from, m.Rows, m.Cols, rows, cols)
+    }
+    return m, nil
+}
+
+// multiply the encrypted matrices a and b together with the other key share holders
+// every party calls MultiplyEncrypted with the same a and b, its own key share
+// and its transport, and all parties get the same encryption of a * b
+//
+// each party i encrypts a random mask R_i, the masked a + R is jointly decrypted,
+// and a * b = (a + R) * b - sum of R_i * b is computed homomorphically
+// the protocol protects against semi-honest parties only, and a party that
+// fails aborts the protocol for all parties, see Transport.Abort
+func MultiplyEncrypted(a, b Matrix, sk *tcpaillier.KeyShare, tr Transport) (product Matrix, err error) {
+    defer abortOnError(tr, &err)
+    pk, err := encryptionKey(a)
+    if err != nil {return Matrix{}, err}
+    b_pk, err := encryptionKey(b)
+    if err != nil {return Matrix{}, err}
+    if !samePubKey(pk.PubKey, b_pk.PubKey) || sk == nil || !samePubKey(pk.PubKey, sk.PubKey) {
+        return Matrix{}, ErrKeyMismatch
+    }
+    if a.Cols != b.Rows {
+        return Matrix{}, fmt.Errorf("matrices a and b are not compatible")
+    }
+    if tr.Parties() < int(pk.K) {
+        return Matrix{}, fmt.Errorf("needed %d parties to decrypt, but got %d", pk.K, tr.Parties())
+    }
+
+    // round 1: share encrypted masks, uniform over the signed plaintext range
+    n_to_s := pk.Cache().NToS
+    r_vals := make([]interface{}, len(a.values))
+    for i := range r_vals {
+        r_i, err := rand.Int(rand.Reader, n_to_s)
+        if err != nil {return Matrix{}, err}
+        r_vals[i] = decodeSigned(r_i, n_to_s)
+    }
+    r, err := NewMatrix(a.Rows, a.Cols, r_vals, Bigint{})
+    if err != nil {return Matrix{}, err}
+    r_enc, err := EncryptMatrix(r, pk)
+    if err != nil {return Matrix{}, err}
+    msgs, err := broadcast(tr, r_enc)
+    if err != nil {return Matrix{}, err}
+    masked := a
+    for j, msg := range msgs {
+        r_j, err := receivedCiphertext(msg, j, pk, a.Rows, a.Cols)
+        if err != nil {return Matrix{}, err}
+        masked, err = masked.Add(r_j)
+        if err != nil {return Matrix{}, err}
+    }
+
+    // round 2: jointly decrypt a + R
+    part, err := PartialDecryptMatrix(masked, sk)
+    if err != nil {return Matrix{}, err}
+    msgs, err = broadcast(tr, part)
+    if err != nil {return Matrix{}, err}
+    parts := make([]PartialDecryption, len(msgs))
+    for j, msg := range msgs {
+        var ok bool
+        parts[j], ok = msg.(PartialDecryption)
+        if !ok {
+            return Matrix{}, fmt.Errorf("party %d sent %T, expected PartialDecryption", j, msg)
+        }
+    }
+    masked_plain, err := CombinePartialDecryptions(pk, parts)
+    if err != nil {return Matrix{}, err}
+
+    // round 3: share encryptions of R_i * b
+    rb, err := r.Multiply(b)
+    if err != nil {return Matrix{}, err}
+    msgs, err = broadcast(tr, rb)
+    if err != nil {return Matrix{}, err}
+    b.Space = publicDJ{pk}
+    c, err := masked_plain.Multiply(b)
+    if err != nil {return Matrix{}, err}
+    for j, msg := range msgs {
+        rb_j, err := receivedCiphertext(msg, j, pk, a.Rows, b.Cols)
+        if err != nil {return Matrix{}, err}
+        c, err = c.Subtract(rb_j)
+        if err != nil {return Matrix{}, err}
+    }
+    c.Space = pk
+    return c, nil
+}
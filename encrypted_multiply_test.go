@@ -0,0 +1,138 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "sync"
+    "testing"
+    "time"
+)
+
+// run f for each of n parties in its own goroutine with connected local transports
+func runParties(n int, f func(tr Transport) (Matrix, error)) ([]Matrix, []error) {
+    trs := NewLocalTransports(n)
+    results := make([]Matrix, n)
+    errs := make([]error, n)
+    var wg sync.WaitGroup
+    for i, tr := range trs {
+        wg.Add(1)
+        go func(i int, tr Transport) {
+            defer wg.Done()
+            results[i], errs[i] = f(tr)
+        }(i, tr)
+    }
+    wg.Wait()
+    return results, errs
+}
+
+func TestMultiplyEncrypted(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(2, 3))
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 3, []int{1, 2, 3, 4, 5, 6})
+    if err != nil {t.Fatal(err)}
+    b, err := NewMatrixFromInt(3, 2, []int{7, 8, 9, 10, 11, 12})
+    if err != nil {t.Fatal(err)}
+    correct, err := a.Multiply(b)
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptMatrix(a, cs)
+    if err != nil {t.Fatal(err)}
+    be, err := EncryptMatrix(b, cs)
+    if err != nil {t.Fatal(err)}
+    t.Run("vanilla", func(t *testing.T) {
+        results, errs := runParties(len(djsks), func(tr Transport) (Matrix, error) {
+            return MultiplyEncrypted(ae, be, djsks[tr.Index()], tr)
+        })
+        for i, err := range errs {
+            if err != nil {t.Fatalf("party %d: %v", i, err)}
+        }
+        for i := range results {
+            for j := range results[i].values {
+                if results[i].values[j].(*big.Int).Cmp(results[0].values[j].(*big.Int)) != 0 {
+                    t.Fatalf("parties 0 and %d got different ciphertexts", i)
+                }
+            }
+        }
+        plain, err := DecryptMatrix(results[0], djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(plain, correct, t)
+    })
+    t.Run("dimension mismatch", func(t *testing.T) {
+        _, errs := runParties(len(djsks), func(tr Transport) (Matrix, error) {
+            return MultiplyEncrypted(ae, ae, djsks[tr.Index()], tr)
+        })
+        for i, err := range errs {
+            if err == nil {t.Errorf("party %d: no error on dimension mismatch", i)}
+        }
+    })
+    t.Run("party fails mid-protocol", func(t *testing.T) {
+        for round := 0; round < 3; round += 1 {
+            errs := runPartiesFailing(t, len(djsks), round, func(tr Transport) (Matrix, error) {
+                return MultiplyEncrypted(ae, be, djsks[tr.Index()], tr)
+            })
+            for i, err := range errs[1:] {
+                if !errors.Is(err, ErrAborted) {t.Errorf("round %d, party %d: expected ErrAborted, got %v", round, i + 1, err)}
+            }
+        }
+    })
+    t.Run("plaintext operand", func(t *testing.T) {
+        _, errs := runParties(len(djsks), func(tr Transport) (Matrix, error) {
+            return MultiplyEncrypted(ae, b, djsks[tr.Index()], tr)
+        })
+        for i, err := range errs {
+            if err == nil {t.Errorf("party %d: no error on plaintext operand", i)}
+        }
+    })
+}
+
+// transport of a party that fails in its Exchange of the given round, counting from 0
+type failingTransport struct {
+    Transport
+    fail_round int
+    round *int
+}
+
+func (tr failingTransport) Exchange(out []interface{}) ([]interface{}, error) {
+    *tr.round += 1
+    if *tr.round - 1 == tr.fail_round {
+        return nil, errors.New("connection lost")
+    }
+    return tr.Transport.Exchange(out)
+}
+
+// runParties where party 0 fails in round fail_round, failing the test if a party hangs
+func runPartiesFailing(t *testing.T, n, fail_round int, f func(tr Transport) (Matrix, error)) []error {
+    done := make(chan []error)
+    go func() {
+        _, errs := runParties(n, func(tr Transport) (Matrix, error) {
+            if tr.Index() == 0 {
+                tr = failingTransport{tr, fail_round, new(int)}
+            }
+            return f(tr)
+        })
+        done <- errs
+    }()
+    select {
+    case errs := <-done:
+        return errs
+    case <-time.After(time.Minute):
+        t.Fatal("parties did not return after a party failed")
+        return nil
+    }
+}
+
+func TestTransportAbort(t *testing.T) {
+    trs := NewLocalTransports(3)
+    errs := make(chan error, 2)
+    for _, tr := range trs[1:] {
+        go func(tr Transport) {
+            _, err := tr.Exchange(make([]interface{}, 3))
+            errs <- err
+        }(tr)
+    }
+    trs[0].Abort(errors.New("invalid input"))
+    for i := 0; i < 2; i += 1 {
+        if err := <-errs; !errors.Is(err, ErrAborted) {t.Errorf("expected ErrAborted, got %v", err)}
+    }
+    _, err := trs[0].Exchange(make([]interface{}, 3))
+    if !errors.Is(err, ErrAborted) {t.Errorf("expected ErrAborted after abort, got %v", err)}
+}
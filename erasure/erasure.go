@@ -0,0 +1,212 @@
+// Package erasure provides Reed-Solomon style erasure coding of byte shards,
+// computed with genmatrix matrices over GF(2^8).
+package erasure
+
+import (
+    "errors"
+    "fmt"
+    "github.com/ontanj/generic-matrix"
+)
+
+var (
+    // fewer shards than data shards are present
+    ErrTooFewShards = errors.New("too few shards to reconstruct the data")
+    // shards differ in size
+    ErrShardSize = errors.New("shards differ in size")
+)
+
+// Encoder encodes data shards into parity shards, such that any data many
+// of the data + parity shards suffice to reconstruct all of them
+type Encoder struct {
+    data, parity int
+    field genmatrix.GF2k
+    // systematic (data + parity) x data encoding matrix, the identity on top
+    // of a Cauchy matrix, any data rows of which are invertible
+    matrix genmatrix.Matrix
+}
+
+// create an encoder of data data shards and parity parity shards,
+// where data + parity is at most 256
+func New(data, parity int) (*Encoder, error) {
+    if data < 1 || parity < 0 {
+        return nil, fmt.Errorf("invalid number of shards: %d data and %d parity", data, parity)
+    }
+    field := genmatrix.NewGF256()
+    if data + parity > field.Size() {
+        return nil, fmt.Errorf("at most %d shards are supported, but got %d", field.Size(), data + parity)
+    }
+    id, err := genmatrix.Identity(data, field)
+    if err != nil {return nil, err}
+    cauchy, err := field.Cauchy(parity, data)
+    if err != nil {return nil, err}
+    m, err := id.ConcatenateVertically(cauchy)
+    if err != nil {return nil, err}
+    return &Encoder{data: data, parity: parity, field: field, matrix: m}, nil
+}
+
+// number of data and parity shards
+func (e *Encoder) Shards() (data, parity int) {
+    return e.data, e.parity
+}
+
+// size of the shards, checking that all non-nil shards have the same size
+func shardSize(shards [][]byte) (int, error) {
+    size := -1
+    for i, s := range shards {
+        if s == nil {
+            continue
+        }
+        if size >= 0 && len(s) != size {
+            return 0, fmt.Errorf("%w: shard %d has %d bytes, expected %d", ErrShardSize, i, len(s), size)
+        }
+        size = len(s)
+    }
+    return size, nil
+}
+
+// the shards as rows of a matrix over the field
+func (e *Encoder) toMatrix(shards [][]byte, size int) (genmatrix.Matrix, error) {
+    vals := make([]interface{}, len(shards)*size)
+    for i, s := range shards {
+        for j, b := range s {
+            vals[i*size+j] = genmatrix.GF2kElement(b)
+        }
+    }
+    return genmatrix.NewMatrix(len(shards), size, vals, e.field)
+}
+
+// write the rows of m into shards, allocating missing shards
+func fromMatrix(m genmatrix.Matrix, shards [][]byte) error {
+    for i := range shards {
+        if shards[i] == nil {
+            shards[i] = make([]byte, m.Cols)
+        }
+        for j := range shards[i] {
+            v, err := m.At(i, j)
+            if err != nil {return err}
+            shards[i][j] = byte(v.(genmatrix.GF2kElement))
+        }
+    }
+    return nil
+}
+
+// compute the parity shards from the data shards
+// shards holds data + parity shards of the same size, the first data of which
+// hold the data, and parity shards that are nil are allocated
+func (e *Encoder) Encode(shards [][]byte, opts ...genmatrix.Option) error {
+    if len(shards) != e.data + e.parity {
+        return fmt.Errorf("expected %d shards, but got %d", e.data + e.parity, len(shards))
+    }
+    for i, s := range shards[:e.data] {
+        if s == nil {
+            return fmt.Errorf("data shard %d is missing", i)
+        }
+    }
+    size, err := shardSize(shards)
+    if err != nil {return err}
+    data, err := e.toMatrix(shards[:e.data], size)
+    if err != nil {return err}
+    coding, err := e.matrix.Submatrix(e.data, e.data + e.parity, 0, e.data)
+    if err != nil {return err}
+    parity, err := coding.Multiply(data, opts...)
+    if err != nil {return err}
+    return fromMatrix(parity, shards[e.data:])
+}
+
+// check that the parity shards match the data shards
+func (e *Encoder) Verify(shards [][]byte, opts ...genmatrix.Option) (bool, error) {
+    if len(shards) != e.data + e.parity {
+        return false, fmt.Errorf("expected %d shards, but got %d", e.data + e.parity, len(shards))
+    }
+    parity := make([][]byte, len(shards))
+    copy(parity, shards[:e.data])
+    err := e.Encode(parity, opts...)
+    if err != nil {return false, err}
+    for i := e.data; i < len(shards); i += 1 {
+        if string(parity[i]) != string(shards[i]) {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// recompute the missing shards, given as nil, from any data many present shards
+// by inverting the rows of the encoding matrix of the present shards
+func (e *Encoder) Reconstruct(shards [][]byte, opts ...genmatrix.Option) error {
+    if len(shards) != e.data + e.parity {
+        return fmt.Errorf("expected %d shards, but got %d", e.data + e.parity, len(shards))
+    }
+    size, err := shardSize(shards)
+    if err != nil {return err}
+    var present, missing []int
+    for i, s := range shards {
+        if s != nil {
+            present = append(present, i)
+        } else {
+            missing = append(missing, i)
+        }
+    }
+    if len(missing) == 0 {
+        return nil
+    }
+    if len(present) < e.data {
+        return fmt.Errorf("%w: %d present, %d needed", ErrTooFewShards, len(present), e.data)
+    }
+    present = present[:e.data]
+    sub, err := e.matrix.SelectRows(present)
+    if err != nil {return err}
+    decoding, err := sub.Inverse()
+    if err != nil {return err}
+    // the rows of the missing shards, applied to the data recovered from the present ones
+    rebuild, err := e.matrix.SelectRows(missing)
+    if err != nil {return err}
+    rebuild, err = rebuild.Multiply(decoding, opts...)
+    if err != nil {return err}
+    present_shards := make([][]byte, len(present))
+    for i, row := range present {
+        present_shards[i] = shards[row]
+    }
+    known, err := e.toMatrix(present_shards, size)
+    if err != nil {return err}
+    rebuilt, err := rebuild.Multiply(known, opts...)
+    if err != nil {return err}
+    rebuilt_shards := make([][]byte, len(missing))
+    err = fromMatrix(rebuilt, rebuilt_shards)
+    if err != nil {return err}
+    for i, row := range missing {
+        shards[row] = rebuilt_shards[i]
+    }
+    return nil
+}
+
+// split data into data + parity shards of equal size, the data shards
+// zero-padded and the parity shards allocated but not yet encoded
+func (e *Encoder) Split(data []byte) [][]byte {
+    size := (len(data) + e.data - 1) / e.data
+    shards := make([][]byte, e.data + e.parity)
+    for i := range shards {
+        shards[i] = make([]byte, size)
+        if i < e.data && i*size < len(data) {
+            copy(shards[i], data[i*size:])
+        }
+    }
+    return shards
+}
+
+// join the data shards into the first size bytes of data
+func (e *Encoder) Join(shards [][]byte, size int) ([]byte, error) {
+    if len(shards) < e.data {
+        return nil, fmt.Errorf("%w: %d present, %d needed", ErrTooFewShards, len(shards), e.data)
+    }
+    data := make([]byte, 0, size)
+    for i, s := range shards[:e.data] {
+        if s == nil {
+            return nil, fmt.Errorf("data shard %d is missing, reconstruct first", i)
+        }
+        data = append(data, s...)
+    }
+    if len(data) < size {
+        return nil, fmt.Errorf("data shards hold %d bytes, expected %d", len(data), size)
+    }
+    return data[:size], nil
+}
@@ -0,0 +1,69 @@
+package erasure
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+)
+
+func TestErasure(t *testing.T) {
+    e, err := New(4, 3)
+    if err != nil {t.Fatal(err)}
+    data := []byte("the quick brown fox jumps over the lazy dog")
+    shards := e.Split(data)
+    err = e.Encode(shards)
+    if err != nil {t.Fatal(err)}
+    ok, err := e.Verify(shards)
+    if err != nil {t.Fatal(err)}
+    if !ok {
+        t.Error("encoded shards failed verification")
+    }
+    t.Run("reconstruct", func(t *testing.T) {
+        for _, lost := range [][]int{{}, {0}, {0, 1, 2}, {4, 5, 6}, {1, 3, 5}, {3, 6}} {
+            damaged := make([][]byte, len(shards))
+            copy(damaged, shards)
+            for _, i := range lost {
+                damaged[i] = nil
+            }
+            err := e.Reconstruct(damaged)
+            if err != nil {t.Fatal(err)}
+            for i := range shards {
+                if !bytes.Equal(damaged[i], shards[i]) {
+                    t.Errorf("lost %v: shard %d reconstructed incorrectly", lost, i)
+                }
+            }
+            joined, err := e.Join(damaged, len(data))
+            if err != nil {t.Fatal(err)}
+            if !bytes.Equal(joined, data) {
+                t.Errorf("lost %v: joined data is %q", lost, joined)
+            }
+        }
+    })
+    t.Run("corrupted", func(t *testing.T) {
+        damaged := make([][]byte, len(shards))
+        copy(damaged, shards)
+        damaged[1] = append([]byte{}, shards[1]...)
+        damaged[1][0] ^= 1
+        ok, err := e.Verify(damaged)
+        if err != nil {t.Fatal(err)}
+        if ok {
+            t.Error("corrupted shards passed verification")
+        }
+    })
+    t.Run("too few shards", func(t *testing.T) {
+        damaged := make([][]byte, len(shards))
+        copy(damaged, shards)
+        damaged[0], damaged[2], damaged[4], damaged[6] = nil, nil, nil, nil
+        err := e.Reconstruct(damaged)
+        if !errors.Is(err, ErrTooFewShards) {t.Errorf("expected ErrTooFewShards, got %v", err)}
+        damaged[0] = shards[0][1:]
+        err = e.Reconstruct(damaged)
+        if !errors.Is(err, ErrShardSize) {t.Errorf("expected ErrShardSize, got %v", err)}
+    })
+    t.Run("invalid", func(t *testing.T) {
+        for _, c := range [][2]int{{0, 2}, {4, -1}, {200, 57}} {
+            _, err := New(c[0], c[1])
+            if err == nil {t.Errorf("created encoder of %d data and %d parity shards", c[0], c[1])}
+        }
+    })
+}
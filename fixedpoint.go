@@ -0,0 +1,334 @@
+package genmatrix
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// Fixed is the fixed-point number Value / 2^Scale, where Value is an element
+// of the integer space the number is encoded in
+type Fixed struct {
+    Value interface{}
+    Scale uint
+}
+
+// FixedPoint encodes real numbers as Fixed elements over the integer space Inner,
+// e.g. Bigint for plaintexts or DJ_public_key for ciphertexts
+// scales are aligned on addition and add up on multiplication and scaling
+type FixedPoint struct {
+    Inner Space
+}
+
+// assert that an element is a Fixed, plain *big.Int factors being integers of scale 0
+func toFixed(v interface{}) (Fixed, error) {
+    switch f := v.(type) {
+    case Fixed:
+        return f, nil
+    case *big.Int:
+        if f != nil {
+            return Fixed{Value: f, Scale: 0}, nil
+        }
+    }
+    return Fixed{}, fmt.Errorf("%w: expected Fixed, but %T", ErrElementType, v)
+}
+
+// bring a and b to the larger of their scales, multiplying the value of the
+// other one by a power of two
+func (f FixedPoint) align(a, b interface{}) (x, y Fixed, err error) {
+    x, err = toFixed(a)
+    if err != nil {return}
+    y, err = toFixed(b)
+    if err != nil {return}
+    if x.Scale < y.Scale {
+        x, err = f.rescaleUp(x, y.Scale)
+    } else if y.Scale < x.Scale {
+        y, err = f.rescaleUp(y, x.Scale)
+    }
+    return
+}
+
+func (f FixedPoint) rescaleUp(a Fixed, scale uint) (Fixed, error) {
+    v, err := f.Inner.Scale(a.Value, new(big.Int).Lsh(big.NewInt(1), scale-a.Scale))
+    if err != nil {return Fixed{}, err}
+    return Fixed{Value: v, Scale: scale}, nil
+}
+
+func (f FixedPoint) Add(a, b interface{}) (interface{}, error) {
+    x, y, err := f.align(a, b)
+    if err != nil {return nil, err}
+    v, err := f.Inner.Add(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Fixed{Value: v, Scale: x.Scale}, nil
+}
+
+func (f FixedPoint) Subtract(a, b interface{}) (interface{}, error) {
+    x, y, err := f.align(a, b)
+    if err != nil {return nil, err}
+    v, err := f.Inner.Subtract(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Fixed{Value: v, Scale: x.Scale}, nil
+}
+
+func (f FixedPoint) Multiply(a, b interface{}) (interface{}, error) {
+    x, err := toFixed(a)
+    if err != nil {return nil, err}
+    y, err := toFixed(b)
+    if err != nil {return nil, err}
+    v, err := f.Inner.Multiply(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Fixed{Value: v, Scale: x.Scale + y.Scale}, nil
+}
+
+// scale a by a plaintext Fixed or *big.Int factor
+func (f FixedPoint) Scale(a, factor interface{}) (interface{}, error) {
+    x, err := toFixed(a)
+    if err != nil {return nil, err}
+    y, err := toFixed(factor)
+    if err != nil {return nil, err}
+    v, err := f.Inner.Scale(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Fixed{Value: v, Scale: x.Scale + y.Scale}, nil
+}
+
+func (f FixedPoint) Scalarspace() bool {
+    return f.Inner.Scalarspace()
+}
+
+// create a plaintext fixed-point matrix, rounding data to frac fractional bits
+func NewFixedMatrix(rows, cols int, data []float64, frac uint) (Matrix, error) {
+    floats := make([]*big.Float, len(data))
+    for i, x := range data {
+        if math.IsNaN(x) || math.IsInf(x, 0) {
+            return Matrix{}, fmt.Errorf("can't encode %v as fixed-point number", x)
+        }
+        floats[i] = big.NewFloat(x)
+    }
+    return NewFixedMatrixFromFloat(rows, cols, floats, frac)
+}
+
+// create a plaintext fixed-point matrix, rounding data to frac fractional bits
+func NewFixedMatrixFromFloat(rows, cols int, data []*big.Float, frac uint) (Matrix, error) {
+    if rows * cols != len(data) {
+        return Matrix{}, fmt.Errorf("Data structure not matching matrix size: %d x %d != %d", rows, cols, len(data))
+    }
+    vals := make([]interface{}, len(data))
+    for i, x := range data {
+        if x == nil || x.IsInf() {
+            return Matrix{}, fmt.Errorf("can't encode %v as fixed-point number", x)
+        }
+        vals[i] = Fixed{Value: roundFloat(new(big.Float).SetMantExp(x, int(frac))), Scale: frac}
+    }
+    return NewMatrix(rows, cols, vals, FixedPoint{Bigint{}})
+}
+
+// round x to the nearest integer, halves away from zero
+func roundFloat(x *big.Float) *big.Int {
+    half := big.NewFloat(0.5)
+    if x.Signbit() {
+        half.Neg(half)
+    }
+    r, _ := new(big.Float).SetPrec(x.Prec() + 2).Add(x, half).Int(nil)
+    return r
+}
+
+// decode the plaintext fixed-point matrix a, in row-major order
+func FixedToFloat(a Matrix) ([]*big.Float, error) {
+    if !isPlainFixed(a) {
+        return nil, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    floats := make([]*big.Float, len(a.values))
+    for i, v := range a.values {
+        x, err := toFixed(v)
+        if err != nil {return nil, elementError(a, i, err)}
+        b, err := toBigint(x.Value)
+        if err != nil {return nil, elementError(a, i, err)}
+        floats[i] = new(big.Float).SetInt(b)
+        floats[i].SetMantExp(floats[i], -int(x.Scale))
+    }
+    return floats, nil
+}
+
+// decode the plaintext fixed-point matrix a, in row-major order, to the nearest float64 values
+func FixedToFloat64(a Matrix) ([]float64, error) {
+    floats, err := FixedToFloat(a)
+    if err != nil {return nil, err}
+    vals := make([]float64, len(floats))
+    for i, x := range floats {
+        vals[i], _ = x.Float64()
+    }
+    return vals, nil
+}
+
+func isPlainFixed(a Matrix) bool {
+    f, ok := a.Space.(FixedPoint)
+    if !ok {
+        return false
+    }
+    _, ok = f.Inner.(Bigint)
+    return ok
+}
+
+// bring every element of the fixed-point matrix a to the given scale
+// lowering the scale rounds to the nearest value and is only possible for
+// plaintexts, so encrypted products are rescaled after decryption
+func Rescale(a Matrix, scale uint) (Matrix, error) {
+    f, ok := a.Space.(FixedPoint)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: space %T is not fixed-point", ErrNotSupported, a.Space)
+    }
+    plain := isPlainFixed(a)
+    return a.apply(func(v interface{}) (interface{}, error) {
+        x, err := toFixed(v)
+        if err != nil {return nil, err}
+        if x.Scale <= scale {
+            return f.rescaleUp(x, scale)
+        }
+        if !plain {
+            return nil, fmt.Errorf("%w: lowering the scale of %T", ErrNotSupported, f.Inner)
+        }
+        b, err := toBigint(x.Value)
+        if err != nil {return nil, err}
+        // round half up by adding half of the divisor before the flooring shift
+        d := x.Scale - scale
+        r := new(big.Int).Lsh(big.NewInt(1), d-1)
+        r.Add(r, b).Rsh(r, d)
+        return Fixed{Value: r, Scale: scale}, nil
+    }, nil)
+}
+
+// split the fixed-point matrix a into an integer matrix in the inner space and the scales
+func fixedParts(a Matrix) (Matrix, []uint, error) {
+    inner_vals := make([]interface{}, len(a.values))
+    scales := make([]uint, len(a.values))
+    for i, v := range a.values {
+        x, err := toFixed(v)
+        if err != nil {return Matrix{}, nil, elementError(a, i, err)}
+        inner_vals[i], scales[i] = x.Value, x.Scale
+    }
+    inner, err := NewMatrix(a.Rows, a.Cols, inner_vals, a.Space.(FixedPoint).Inner)
+    return inner, scales, err
+}
+
+// join an integer matrix and scales into a fixed-point matrix
+func withScales(inner Matrix, scales []uint) (Matrix, error) {
+    vals := make([]interface{}, len(inner.values))
+    for i, v := range inner.values {
+        vals[i] = Fixed{Value: v, Scale: scales[i]}
+    }
+    return NewMatrix(inner.Rows, inner.Cols, vals, FixedPoint{inner.Space})
+}
+
+// encrypt the plaintext fixed-point matrix a with pk, keeping the scale of every element
+func EncryptFixed(a Matrix, pk DJ_public_key, opts ...Option) (Matrix, error) {
+    return EncryptFixedContext(context.Background(), a, pk, opts...)
+}
+
+// EncryptFixed, stopping with ctx.Err() once ctx is done
+func EncryptFixedContext(ctx context.Context, a Matrix, pk DJ_public_key, opts ...Option) (Matrix, error) {
+    if !isPlainFixed(a) {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    inner, scales, err := fixedParts(a)
+    if err != nil {return Matrix{}, err}
+    cipher, err := EncryptMatrixContext(ctx, inner, pk, opts...)
+    if err != nil {return Matrix{}, err}
+    return withScales(cipher, scales)
+}
+
+// decrypt the encrypted fixed-point matrix cipher into a plaintext fixed-point matrix,
+// the scales of which are those accumulated by the computation on cipher
+func DecryptFixed(cipher Matrix, sks []*tcpaillier.KeyShare, opts ...Option) (Matrix, error) {
+    return DecryptFixedContext(context.Background(), cipher, sks, opts...)
+}
+
+// DecryptFixed, stopping with ctx.Err() once ctx is done
+func DecryptFixedContext(ctx context.Context, cipher Matrix, sks []*tcpaillier.KeyShare, opts ...Option) (Matrix, error) {
+    f, ok := cipher.Space.(FixedPoint)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotEncrypted, cipher.Space)
+    }
+    if _, ok := f.Inner.(DJ_public_key); !ok {
+        return Matrix{}, fmt.Errorf("%w: fixed-point space over %T", ErrNotEncrypted, f.Inner)
+    }
+    inner, scales, err := fixedParts(cipher)
+    if err != nil {return Matrix{}, err}
+    plain, err := DecryptMatrixContext(ctx, inner, sks, opts...)
+    if err != nil {return Matrix{}, err}
+    return withScales(plain, scales)
+}
+
+func (f FixedPoint) SpaceName() string {return "fixed"}
+
+// the name and parameters of the inner space
+func (f FixedPoint) MarshalSpace() ([]byte, error) {
+    s, ok := f.Inner.(SerializableSpace)
+    if !ok {
+        return nil, fmt.Errorf("%w: %T is not serializable", ErrNotSupported, f.Inner)
+    }
+    params, err := s.MarshalSpace()
+    if err != nil {return nil, err}
+    var e encoder
+    e.bytes([]byte(s.SpaceName()))
+    e.bytes(params)
+    return e.buf, nil
+}
+
+// the scale followed by the encoding of the value in the inner space
+func (f FixedPoint) MarshalElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    s, ok := f.Inner.(SerializableSpace)
+    if !ok {
+        return nil, fmt.Errorf("%w: %T is not serializable", ErrNotSupported, f.Inner)
+    }
+    x, err := toFixed(a)
+    if err != nil {return nil, err}
+    v, err := s.MarshalElement(x.Value)
+    if err != nil {return nil, err}
+    var e encoder
+    e.uvarint(uint64(x.Scale))
+    e.bytes(v)
+    return e.buf, nil
+}
+
+func (f FixedPoint) UnmarshalElement(b []byte) (interface{}, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    s, ok := f.Inner.(SerializableSpace)
+    if !ok {
+        return nil, fmt.Errorf("%w: %T is not serializable", ErrNotSupported, f.Inner)
+    }
+    d := decoder{buf: b}
+    scale := d.uvarint()
+    v_bytes := d.bytes()
+    if d.err != nil {return nil, d.err}
+    if len(d.buf) != 0 || scale > math.MaxUint32 {
+        return nil, errors.New("malformed fixed-point element")
+    }
+    v, err := s.UnmarshalElement(v_bytes)
+    if err != nil {return nil, err}
+    return Fixed{Value: v, Scale: uint(scale)}, nil
+}
+
+func init() {
+    RegisterSpace("fixed", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        name, inner_params := string(d.bytes()), d.bytes()
+        if d.err != nil {return nil, d.err}
+        registry_mu.RLock()
+        f, ok := registry[name]
+        registry_mu.RUnlock()
+        if !ok {
+            return nil, fmt.Errorf("%w: %q", ErrUnknownSpace, name)
+        }
+        inner, err := f(inner_params)
+        if err != nil {return nil, err}
+        return FixedPoint{inner}, nil
+    })
+}
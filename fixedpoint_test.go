@@ -0,0 +1,145 @@
+package genmatrix
+
+import (
+    "errors"
+    "math"
+    "math/big"
+    "testing"
+)
+
+func compareFloats(a Matrix, expected []float64, tolerance float64, t *testing.T) {
+    vals, err := FixedToFloat64(a)
+    if err != nil {t.Fatal(err)}
+    if len(vals) != len(expected) {
+        t.Fatalf("expected %d values, got %d", len(expected), len(vals))
+    }
+    for i := range vals {
+        if math.Abs(vals[i] - expected[i]) > tolerance {
+            t.Errorf("element %d: expected %v, got %v", i, expected[i], vals[i])
+        }
+    }
+}
+
+func TestFixedPoint(t *testing.T) {
+    a, err := NewFixedMatrix(2, 2, []float64{1.5, -0.25, 3.125, 2}, 16)
+    if err != nil {t.Fatal(err)}
+    b, err := NewFixedMatrix(2, 2, []float64{0.5, 1, -2, 0.75}, 8)
+    if err != nil {t.Fatal(err)}
+    t.Run("round trip", func(t *testing.T) {
+        compareFloats(a, []float64{1.5, -0.25, 3.125, 2}, 0, t)
+        c, err := NewFixedMatrix(1, 3, []float64{math.Pi, -math.Pi, 1e-9}, 20)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{math.Pi, -math.Pi, 0}, math.Ldexp(1, -21), t)
+    })
+    t.Run("add with different scales", func(t *testing.T) {
+        c, err := a.Add(b)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{2, 0.75, 1.125, 2.75}, 0, t)
+        v, _ := c.At(0, 0)
+        if v.(Fixed).Scale != 16 {
+            t.Errorf("expected scale 16, got %d", v.(Fixed).Scale)
+        }
+        c, err = b.Subtract(a)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{-1, 1.25, -5.125, -1.25}, 0, t)
+    })
+    t.Run("multiply", func(t *testing.T) {
+        c, err := a.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{1.25, 1.3125, -2.4375, 4.625}, 0, t)
+        v, _ := c.At(0, 0)
+        if v.(Fixed).Scale != 24 {
+            t.Errorf("expected scale 24, got %d", v.(Fixed).Scale)
+        }
+        c, err = c.Scale(Fixed{Value: big.NewInt(1), Scale: 1})
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{0.625, 0.65625, -1.21875, 2.3125}, 0, t)
+    })
+    t.Run("rescale", func(t *testing.T) {
+        c, err := NewFixedMatrix(1, 3, []float64{0.3, -0.3, 0.75}, 16)
+        if err != nil {t.Fatal(err)}
+        c, err = Rescale(c, 2)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{0.25, -0.25, 0.75}, 0, t)
+        c, err = Rescale(c, 10)
+        if err != nil {t.Fatal(err)}
+        v, _ := c.At(0, 2)
+        if v.(Fixed).Scale != 10 {
+            t.Errorf("expected scale 10, got %d", v.(Fixed).Scale)
+        }
+        compareFloats(c, []float64{0.25, -0.25, 0.75}, 0, t)
+    })
+    t.Run("invalid", func(t *testing.T) {
+        _, err := NewFixedMatrix(1, 1, []float64{math.NaN()}, 8)
+        if err == nil {t.Error("encoded NaN")}
+        _, err = NewFixedMatrix(1, 1, []float64{math.Inf(-1)}, 8)
+        if err == nil {t.Error("encoded infinity")}
+        plain, err := NewMatrixFromInt(1, 1, []int{1})
+        if err != nil {t.Fatal(err)}
+        // integers are fixed-point numbers of scale 0
+        c, err := NewFixedMatrix(1, 1, []float64{0.5}, 4)
+        if err != nil {t.Fatal(err)}
+        c, err = c.Add(plain)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{1.5}, 0, t)
+        _, err = FixedToFloat(plain)
+        if !errors.Is(err, ErrNotPlaintext) {t.Errorf("expected ErrNotPlaintext, got %v", err)}
+    })
+}
+
+func TestEncryptedFixedPoint(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    weights, err := NewFixedMatrix(1, 3, []float64{0.5, 0.25, 1.5}, 8)
+    if err != nil {t.Fatal(err)}
+    data, err := NewFixedMatrix(3, 1, []float64{3.5, 10.125, 0.0625}, 12)
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptFixed(data, cs)
+    if err != nil {t.Fatal(err)}
+    // plaintext weights times encrypted data, adding an encrypted offset of another scale
+    c, err := weights.Multiply(ae)
+    if err != nil {t.Fatal(err)}
+    offset, err := NewFixedMatrix(1, 1, []float64{1.75}, 4)
+    if err != nil {t.Fatal(err)}
+    offset, err = EncryptFixed(offset, cs)
+    if err != nil {t.Fatal(err)}
+    c, err = c.Add(offset)
+    if err != nil {t.Fatal(err)}
+    _, err = Rescale(c, 8)
+    if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+    c, err = DecryptFixed(c, djsks)
+    if err != nil {t.Fatal(err)}
+    v, _ := c.At(0, 0)
+    if v.(Fixed).Scale != 20 {
+        t.Errorf("expected scale 20, got %d", v.(Fixed).Scale)
+    }
+    compareFloats(c, []float64{0.5*3.5 + 0.25*10.125 + 1.5*0.0625 + 1.75}, 0, t)
+    c, err = Rescale(c, 8)
+    if err != nil {t.Fatal(err)}
+    compareFloats(c, []float64{0.5*3.5 + 0.25*10.125 + 1.5*0.0625 + 1.75}, 1.0/512, t)
+    t.Run("negative values", func(t *testing.T) {
+        weights, err := NewFixedMatrix(1, 3, []float64{0.5, -0.25, 1.5}, 8)
+        if err != nil {t.Fatal(err)}
+        c, err := weights.Multiply(ae)
+        if err != nil {t.Fatal(err)}
+        offset, err := NewFixedMatrix(1, 1, []float64{-1.75}, 4)
+        if err != nil {t.Fatal(err)}
+        offset, err = EncryptFixed(offset, cs)
+        if err != nil {t.Fatal(err)}
+        c, err = c.Add(offset)
+        if err != nil {t.Fatal(err)}
+        c, err = DecryptFixed(c, djsks)
+        if err != nil {t.Fatal(err)}
+        compareFloats(c, []float64{0.5*3.5 - 0.25*10.125 + 1.5*0.0625 - 1.75}, 0, t)
+    })
+    t.Run("serialization", func(t *testing.T) {
+        b, err := ae.MarshalBinary()
+        if err != nil {t.Fatal(err)}
+        var d Matrix
+        err = d.UnmarshalBinary(b)
+        if err != nil {t.Fatal(err)}
+        d, err = DecryptFixed(d, djsks)
+        if err != nil {t.Fatal(err)}
+        compareFloats(d, []float64{3.5, 10.125, 0.0625}, 0, t)
+    })
+}
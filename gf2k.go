@@ -0,0 +1,321 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+)
+
+// irreducible polynomials for which x generates the multiplicative group
+const (
+    // x^8 + x^4 + x^3 + x^2 + 1
+    PolyGF256 uint32 = 0x11d
+    // x^16 + x^12 + x^3 + x + 1
+    PolyGF65536 uint32 = 0x1100b
+)
+
+// GF2kElement is an element of a binary extension field, bit i being the
+// coefficient of x^i of a polynomial of degree below k
+type GF2kElement uint16
+
+// GF2k is the field GF(2^k), 1 <= k <= 16, of polynomials over GF(2) modulo
+// an irreducible polynomial, with elements GF2kElement
+// multiplication and inversion use log and exp tables
+type GF2k struct {
+    *gf2kTables
+}
+
+type gf2kTables struct {
+    k uint
+    poly uint32
+    // exp[i] = g^i for a generator g, repeated to avoid reducing sums of logs
+    exp []GF2kElement
+    // log[g^i] = i, log[0] is unused
+    log []int
+}
+
+// create GF(2^k) modulo the irreducible polynomial poly of degree k, where
+// bit i of poly is the coefficient of x^i
+func NewGF2k(k uint, poly uint32) (GF2k, error) {
+    if k < 1 || k > 16 {
+        return GF2k{}, fmt.Errorf("field size 2^%d not supported, k must be in [1, 16]", k)
+    }
+    if poly >> k != 1 {
+        return GF2k{}, fmt.Errorf("polynomial %#x is not of degree %d", poly, k)
+    }
+    if !irreducible(poly, k) {
+        return GF2k{}, fmt.Errorf("polynomial %#x is not irreducible", poly)
+    }
+    order := 1 << k - 1
+    t := &gf2kTables{k: k, poly: poly, exp: make([]GF2kElement, 2*order), log: make([]int, order+1)}
+    // the multiplicative group is cyclic, search for a generator
+    for g := uint32(1); !t.generate(g, order); g += 1 {
+    }
+    for i := order; i < 2*order; i += 1 {
+        t.exp[i] = t.exp[i-order]
+    }
+    return GF2k{t}, nil
+}
+
+// fill the tables with the powers of g, true if g generates the multiplicative group
+func (t *gf2kTables) generate(g uint32, order int) bool {
+    x := uint32(1)
+    for i := 0; i < order; i += 1 {
+        if i > 0 && x == 1 {
+            return false
+        }
+        t.exp[i] = GF2kElement(x)
+        t.log[x] = i
+        x = polyMulMod(x, g, t.poly, t.k)
+    }
+    return x == 1
+}
+
+// GF(2^8) modulo PolyGF256
+func NewGF256() GF2k {
+    f, err := NewGF2k(8, PolyGF256)
+    if err != nil {
+        panic(err)
+    }
+    return f
+}
+
+// product of polynomials a and b of degree below k, modulo poly of degree k
+func polyMulMod(a, b, poly uint32, k uint) uint32 {
+    var r uint32
+    for ; b != 0; b >>= 1 {
+        if b & 1 == 1 {
+            r ^= a
+        }
+        a <<= 1
+        if a >> k == 1 {
+            a ^= poly
+        }
+    }
+    return r
+}
+
+// remainder of a divided by b over GF(2)
+func polyMod(a, b uint32) uint32 {
+    db := bitLen32(b)
+    for da := bitLen32(a); da >= db; da = bitLen32(a) {
+        a ^= b << (da - db)
+    }
+    return a
+}
+
+func bitLen32(a uint32) int {
+    n := 0
+    for ; a != 0; a >>= 1 {
+        n += 1
+    }
+    return n
+}
+
+// Ben-Or test: poly of degree k is irreducible if gcd(x^(2^i) - x, poly) = 1 for i <= k/2
+func irreducible(poly uint32, k uint) bool {
+    if k == 1 {
+        return true
+    }
+    x := uint32(2)
+    x_pow := x
+    for i := uint(1); i <= k/2; i += 1 {
+        x_pow = polyMulMod(x_pow, x_pow, poly, k)
+        a, b := poly, x_pow ^ x
+        for b != 0 {
+            a, b = b, polyMod(a, b)
+        }
+        if a != 1 {
+            return false
+        }
+    }
+    return true
+}
+
+// the number of elements, 2^k
+func (f GF2k) Size() int {
+    return 1 << f.k
+}
+
+// the irreducible polynomial of the field
+func (f GF2k) Poly() uint32 {
+    return f.poly
+}
+
+// assert that an element is a GF2kElement of the field
+func (f GF2k) toElement(v interface{}) (GF2kElement, error) {
+    a, ok := v.(GF2kElement)
+    if !ok {
+        return 0, fmt.Errorf("%w: expected GF2kElement, but %T", ErrElementType, v)
+    }
+    if int(a) >= f.Size() {
+        return 0, fmt.Errorf("%w: %#x is not in GF(2^%d)", ErrElementType, a, f.k)
+    }
+    return a, nil
+}
+
+func (f GF2k) assertElements(a, b interface{}) (x, y GF2kElement, err error) {
+    x, err = f.toElement(a)
+    if err != nil {return}
+    y, err = f.toElement(b)
+    return
+}
+
+func (f GF2k) mul(a, b GF2kElement) GF2kElement {
+    if a == 0 || b == 0 {
+        return 0
+    }
+    return f.exp[f.log[a] + f.log[b]]
+}
+
+// addition and subtraction are both exclusive or
+func (f GF2k) Add(a, b interface{}) (interface{}, error) {
+    x, y, err := f.assertElements(a, b)
+    if err != nil {return nil, err}
+    return x ^ y, nil
+}
+
+func (f GF2k) Subtract(a, b interface{}) (interface{}, error) {
+    return f.Add(a, b)
+}
+
+func (f GF2k) Multiply(a, b interface{}) (interface{}, error) {
+    x, y, err := f.assertElements(a, b)
+    if err != nil {return nil, err}
+    return f.mul(x, y), nil
+}
+
+func (f GF2k) Scale(a, b interface{}) (interface{}, error) {
+    return f.Multiply(a, b)
+}
+
+func (f GF2k) Scalarspace() bool {
+    return true
+}
+
+func (f GF2k) Inverse(a interface{}) (interface{}, error) {
+    x, err := f.toElement(a)
+    if err != nil {return nil, err}
+    if x == 0 {
+        return nil, fmt.Errorf("%w: 0", ErrNotInvertible)
+    }
+    return f.exp[(f.Size() - 1 - f.log[x]) % (f.Size() - 1)], nil
+}
+
+func (f GF2k) IsZero(a interface{}) bool {
+    x, err := f.toElement(a)
+    return err == nil && x == 0
+}
+
+func (f GF2k) Zero() interface{} {
+    return GF2kElement(0)
+}
+
+func (f GF2k) One() interface{} {
+    return GF2kElement(1)
+}
+
+// every element is its own negation
+func (f GF2k) Negate(a interface{}) (interface{}, error) {
+    return f.toElement(a)
+}
+
+func (f GF2k) Equal(a, b interface{}) (bool, error) {
+    x, y, err := f.assertElements(a, b)
+    if err != nil {return false, err}
+    return x == y, nil
+}
+
+// create a new Matrix in f from int values, which must be in [0, 2^k)
+func (f GF2k) NewMatrixFromInt(rows, cols int, data []int) (Matrix, error) {
+    if data == nil {
+        return NewMatrix(rows, cols, nil, f)
+    }
+    vals := make([]interface{}, len(data))
+    for i, x := range data {
+        if x < 0 || x >= f.Size() {
+            return Matrix{}, fmt.Errorf("%d is not in GF(2^%d)", x, f.k)
+        }
+        vals[i] = GF2kElement(x)
+    }
+    return NewMatrix(rows, cols, vals, f)
+}
+
+// the rows x cols Vandermonde matrix with element (i, j) = i^j, treating i as
+// an element of f, any cols of whose rows are linearly independent
+func (f GF2k) Vandermonde(rows, cols int) (Matrix, error) {
+    if rows > f.Size() {
+        return Matrix{}, fmt.Errorf("GF(2^%d) has too few elements for %d rows", f.k, rows)
+    }
+    vals := make([]interface{}, rows*cols)
+    for i := 0; i < rows; i += 1 {
+        x := GF2kElement(1)
+        for j := 0; j < cols; j += 1 {
+            vals[i*cols+j] = x
+            x = f.mul(x, GF2kElement(i))
+        }
+    }
+    return NewMatrix(rows, cols, vals, f)
+}
+
+// the rows x cols Cauchy matrix with element (i, j) = 1 / (x_i + y_j), for
+// x_i = i and y_j = rows + j, every square submatrix of which is invertible
+func (f GF2k) Cauchy(rows, cols int) (Matrix, error) {
+    if rows + cols > f.Size() {
+        return Matrix{}, fmt.Errorf("GF(2^%d) has too few elements for a %d x %d Cauchy matrix", f.k, rows, cols)
+    }
+    vals := make([]interface{}, rows*cols)
+    for i := 0; i < rows; i += 1 {
+        for j := 0; j < cols; j += 1 {
+            // x_i != y_j, so the sum is non-zero
+            inv, err := f.Inverse(GF2kElement(i) ^ GF2kElement(rows+j))
+            if err != nil {return Matrix{}, err}
+            vals[i*cols+j] = inv
+        }
+    }
+    return NewMatrix(rows, cols, vals, f)
+}
+
+func (f GF2k) SpaceName() string {return "gf2k"}
+
+func (f GF2k) MarshalSpace() ([]byte, error) {
+    var e encoder
+    e.uvarint(uint64(f.k))
+    e.uvarint(uint64(f.poly))
+    return e.buf, nil
+}
+
+func (f GF2k) MarshalElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    x, err := f.toElement(a)
+    if err != nil {return nil, err}
+    var e encoder
+    e.uvarint(uint64(x))
+    return e.buf, nil
+}
+
+func (f GF2k) UnmarshalElement(b []byte) (interface{}, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    d := decoder{buf: b}
+    x := d.uvarint()
+    if d.err != nil {return nil, d.err}
+    if x >= uint64(f.Size()) || len(d.buf) != 0 {
+        return nil, errors.New("malformed GF(2^k) element")
+    }
+    return GF2kElement(x), nil
+}
+
+func init() {
+    RegisterSpace("gf2k", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        k, poly := d.uvarint(), d.uvarint()
+        if d.err != nil {return nil, d.err}
+        if k > 16 || poly >> 17 != 0 {
+            return nil, errors.New("malformed GF(2^k) parameters")
+        }
+        return NewGF2k(uint(k), uint32(poly))
+    })
+}
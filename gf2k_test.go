@@ -0,0 +1,111 @@
+package genmatrix
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestGF2k(t *testing.T) {
+    t.Run("field axioms", func(t *testing.T) {
+        for _, c := range []struct{k uint; poly uint32}{{1, 0x3}, {4, 0x13}, {8, PolyGF256}, {8, 0x11b}, {16, PolyGF65536}} {
+            f, err := NewGF2k(c.k, c.poly)
+            if err != nil {t.Fatal(err)}
+            step := 1
+            if c.k > 8 {
+                step = 97
+            }
+            for a := 1; a < f.Size(); a += step {
+                x := GF2kElement(a)
+                inv, err := f.Inverse(x)
+                if err != nil {t.Fatal(err)}
+                if one, _ := f.Multiply(x, inv); one != f.One() {
+                    t.Errorf("wrong inverse of %#x in GF(2^%d)", a, c.k)
+                }
+                // multiplication by the log tables agrees with polynomial multiplication
+                for b := 0; b < f.Size(); b += step * 3 {
+                    p, _ := f.Multiply(x, GF2kElement(b))
+                    if uint32(p.(GF2kElement)) != polyMulMod(uint32(a), uint32(b), c.poly, c.k) {
+                        t.Errorf("%#x * %#x in GF(2^%d): got %#x", a, b, c.k, p)
+                    }
+                }
+            }
+        }
+    })
+    t.Run("standard polynomials are primitive", func(t *testing.T) {
+        for _, f := range []GF2k{NewGF256(), func() GF2k {f, _ := NewGF2k(16, PolyGF65536); return f}()} {
+            if f.exp[1] != 2 {
+                t.Errorf("x does not generate GF(2^%d) modulo %#x", f.k, f.poly)
+            }
+        }
+    })
+    t.Run("invalid polynomial", func(t *testing.T) {
+        // x^8 + 1 = (x + 1)^8
+        for _, c := range []struct{k uint; poly uint32}{{8, 0x101}, {8, 0x11}, {17, 0x20009}, {0, 1}} {
+            _, err := NewGF2k(c.k, c.poly)
+            if err == nil {t.Errorf("accepted polynomial %#x for k = %d", c.poly, c.k)}
+        }
+        f := NewGF256()
+        _, err := f.Inverse(f.Zero())
+        if !errors.Is(err, ErrNotInvertible) {t.Errorf("expected ErrNotInvertible, got %v", err)}
+        _, err = f.Add(GF2kElement(0x100), GF2kElement(1))
+        if !errors.Is(err, ErrElementType) {t.Errorf("expected ErrElementType, got %v", err)}
+    })
+}
+
+func TestGF2kMatrix(t *testing.T) {
+    f := NewGF256()
+    t.Run("vandermonde", func(t *testing.T) {
+        v, err := f.Vandermonde(6, 4)
+        if err != nil {t.Fatal(err)}
+        // any 4 rows form an invertible matrix
+        for _, rows := range [][]int{{0, 1, 2, 3}, {2, 3, 4, 5}, {0, 2, 4, 5}} {
+            sub, err := v.SelectRows(rows)
+            if err != nil {t.Fatal(err)}
+            inv, err := sub.Inverse()
+            if err != nil {t.Fatal(err)}
+            id, err := inv.Multiply(sub)
+            if err != nil {t.Fatal(err)}
+            correct, err := Identity(4, f)
+            if err != nil {t.Fatal(err)}
+            if eq, err := id.Equal(correct); err != nil || !eq {
+                t.Errorf("rows %v: inverse times matrix is not the identity", rows)
+            }
+        }
+    })
+    t.Run("cauchy", func(t *testing.T) {
+        c, err := f.Cauchy(3, 5)
+        if err != nil {t.Fatal(err)}
+        rank, err := c.Rank()
+        if err != nil {t.Fatal(err)}
+        if rank != 3 {
+            t.Errorf("expected rank 3, got %d", rank)
+        }
+        sub, err := c.SelectCols([]int{1, 3, 4})
+        if err != nil {t.Fatal(err)}
+        det, err := sub.Determinant()
+        if err != nil {t.Fatal(err)}
+        if f.IsZero(det) {
+            t.Error("square submatrix of Cauchy matrix is singular")
+        }
+        _, err = f.Cauchy(200, 57)
+        if err == nil {t.Error("built Cauchy matrix with repeated points")}
+    })
+    t.Run("singular", func(t *testing.T) {
+        a, err := f.NewMatrixFromInt(2, 2, []int{3, 5, 3, 5})
+        if err != nil {t.Fatal(err)}
+        _, err = a.Inverse()
+        if !errors.Is(err, ErrSingular) {t.Errorf("expected ErrSingular, got %v", err)}
+    })
+    t.Run("serialization", func(t *testing.T) {
+        a, err := f.Vandermonde(3, 3)
+        if err != nil {t.Fatal(err)}
+        enc, err := a.MarshalJSON()
+        if err != nil {t.Fatal(err)}
+        var b Matrix
+        err = b.UnmarshalJSON(enc)
+        if err != nil {t.Fatal(err)}
+        if eq, err := a.Equal(b); err != nil || !eq {
+            t.Errorf("matrix changed in serialization: %v", err)
+        }
+    })
+}
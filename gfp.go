@@ -0,0 +1,324 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+    "math/bits"
+)
+
+// primes p with large powers of two dividing p - 1, for number-theoretic transforms
+const (
+    // 119 * 2^23 + 1
+    NTTPrime30 uint64 = 998244353
+    // 15 * 2^27 + 1
+    NTTPrime31 uint64 = 2013265921
+    // 29 * 2^57 + 1
+    NTTPrime62 uint64 = 4179340454199820289
+)
+
+// FpElement is an element of a prime field Fp, stored in Montgomery form
+// convert with Fp.Element and Fp.Uint64, never directly
+type FpElement uint64
+
+// Fp is the prime field GF(p) for an odd prime p < 2^63
+// elements are FpElement, multiplied with Montgomery reduction so that no
+// arithmetic allocates
+type Fp struct {
+    p uint64
+    // -p^-1 mod 2^64
+    p_inv uint64
+    // 2^128 mod p, for conversion into Montgomery form
+    r2 uint64
+}
+
+// create the prime field of integers modulo p
+func NewFp(p uint64) (Fp, error) {
+    if p < 3 || p >= 1 << 63 || p % 2 == 0 {
+        return Fp{}, fmt.Errorf("modulus must be an odd prime below 2^63, but is %d", p)
+    }
+    // exact for numbers below 2^64
+    if !new(big.Int).SetUint64(p).ProbablyPrime(0) {
+        return Fp{}, fmt.Errorf("modulus %d is not prime", p)
+    }
+    // Newton iteration for p^-1 mod 2^64, each step doubling the correct bits
+    inv := p
+    for i := 0; i < 5; i += 1 {
+        inv *= 2 - p*inv
+    }
+    r2 := new(big.Int).Lsh(big.NewInt(1), 128)
+    r2.Mod(r2, new(big.Int).SetUint64(p))
+    return Fp{p: p, p_inv: -inv, r2: r2.Uint64()}, nil
+}
+
+// the prime p
+func (f Fp) Modulus() uint64 {
+    return f.p
+}
+
+// Montgomery reduction of hi * 2^64 + lo < p * 2^64, giving (hi * 2^64 + lo) / 2^64 mod p
+func (f Fp) reduce(hi, lo uint64) uint64 {
+    m := lo * f.p_inv
+    m_hi, m_lo := bits.Mul64(m, f.p)
+    _, carry := bits.Add64(lo, m_lo, 0)
+    // hi, m_hi < p < 2^63, so the sum can't overflow
+    r := hi + m_hi + carry
+    if r >= f.p {
+        r -= f.p
+    }
+    return r
+}
+
+func (f Fp) mul(a, b uint64) uint64 {
+    return f.reduce(bits.Mul64(a, b))
+}
+
+// x mod p as an element of the field
+func (f Fp) Element(x uint64) FpElement {
+    return FpElement(f.mul(x % f.p, f.r2))
+}
+
+// x mod p as an element of the field
+func (f Fp) ElementFromInt(x int64) FpElement {
+    if x < 0 {
+        return FpElement(f.sub(0, f.mul(uint64(-(x + 1)) % f.p + 1, f.r2)))
+    }
+    return f.Element(uint64(x))
+}
+
+// the value of a in [0, p)
+func (f Fp) Uint64(a FpElement) uint64 {
+    return f.reduce(0, uint64(a))
+}
+
+func (f Fp) sub(a, b uint64) uint64 {
+    if a >= b {
+        return a - b
+    }
+    return a + (f.p - b)
+}
+
+// assert that an element is an FpElement
+func toFp(v interface{}) (uint64, error) {
+    a, ok := v.(FpElement)
+    if !ok {
+        return 0, fmt.Errorf("%w: expected FpElement, but %T", ErrElementType, v)
+    }
+    return uint64(a), nil
+}
+
+func assertFp(a, b interface{}) (x, y uint64, err error) {
+    x, err = toFp(a)
+    if err != nil {return}
+    y, err = toFp(b)
+    return
+}
+
+func (f Fp) Add(a, b interface{}) (interface{}, error) {
+    x, y, err := assertFp(a, b)
+    if err != nil {return nil, err}
+    // x + y < 2p < 2^64
+    s := x + y
+    if s >= f.p {
+        s -= f.p
+    }
+    return FpElement(s), nil
+}
+
+func (f Fp) Subtract(a, b interface{}) (interface{}, error) {
+    x, y, err := assertFp(a, b)
+    if err != nil {return nil, err}
+    return FpElement(f.sub(x, y)), nil
+}
+
+func (f Fp) Multiply(a, b interface{}) (interface{}, error) {
+    x, y, err := assertFp(a, b)
+    if err != nil {return nil, err}
+    return FpElement(f.mul(x, y)), nil
+}
+
+// scale by an FpElement or *big.Int factor
+func (f Fp) Scale(a, b interface{}) (interface{}, error) {
+    if i, ok := b.(*big.Int); ok && i != nil {
+        b = f.Element(new(big.Int).Mod(i, new(big.Int).SetUint64(f.p)).Uint64())
+    }
+    return f.Multiply(a, b)
+}
+
+func (f Fp) Scalarspace() bool {
+    return true
+}
+
+func (f Fp) exp(a, e uint64) uint64 {
+    r := uint64(f.One().(FpElement))
+    for ; e > 0; e >>= 1 {
+        if e & 1 == 1 {
+            r = f.mul(r, a)
+        }
+        a = f.mul(a, a)
+    }
+    return r
+}
+
+// a^e
+func (f Fp) Exp(a FpElement, e uint64) FpElement {
+    return FpElement(f.exp(uint64(a), e))
+}
+
+// multiplicative inverse of a by Fermat's little theorem
+func (f Fp) Inverse(a interface{}) (interface{}, error) {
+    x, err := toFp(a)
+    if err != nil {return nil, err}
+    if x == 0 {
+        return nil, fmt.Errorf("%w: 0 modulo %d", ErrNotInvertible, f.p)
+    }
+    return FpElement(f.exp(x, f.p - 2)), nil
+}
+
+func (f Fp) IsZero(a interface{}) bool {
+    x, err := toFp(a)
+    return err == nil && x == 0
+}
+
+func (f Fp) Zero() interface{} {
+    return FpElement(0)
+}
+
+func (f Fp) One() interface{} {
+    return f.Element(1)
+}
+
+func (f Fp) Negate(a interface{}) (interface{}, error) {
+    x, err := toFp(a)
+    if err != nil {return nil, err}
+    return FpElement(f.sub(0, x)), nil
+}
+
+func (f Fp) Equal(a, b interface{}) (bool, error) {
+    x, y, err := assertFp(a, b)
+    if err != nil {return false, err}
+    return x == y, nil
+}
+
+// primitive n:th root of unity, which exists if n divides p - 1
+func (f Fp) RootOfUnity(n uint64) (FpElement, error) {
+    if n == 0 || (f.p - 1) % n != 0 {
+        return 0, fmt.Errorf("no root of unity of order %d modulo %d", n, f.p)
+    }
+    primes := primeFactors(n)
+    one := uint64(f.One().(FpElement))
+    // x^((p-1)/n) has order n unless a power n/q, for a prime q dividing n, is 1
+    for x := uint64(2); x < f.p; x += 1 {
+        w := f.exp(uint64(f.Element(x)), (f.p - 1) / n)
+        primitive := true
+        for _, q := range primes {
+            if f.exp(w, n / q) == one {
+                primitive = false
+                break
+            }
+        }
+        if primitive {
+            return FpElement(w), nil
+        }
+    }
+    return 0, errors.New("no root of unity found")
+}
+
+// distinct prime factors of n by trial division
+func primeFactors(n uint64) (primes []uint64) {
+    for q := uint64(2); q*q <= n; q += 1 {
+        if n % q == 0 {
+            primes = append(primes, q)
+            for n % q == 0 {
+                n /= q
+            }
+        }
+    }
+    if n > 1 {
+        primes = append(primes, n)
+    }
+    return
+}
+
+// reduce the elements of the Bigint matrix a modulo p, giving a matrix in f
+func (f Fp) Reduce(a Matrix) (Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    p := new(big.Int).SetUint64(f.p)
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        b, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        vals[i] = f.Element(new(big.Int).Mod(b, p).Uint64())
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, f)
+}
+
+// convert the matrix a in f to a Bigint matrix with elements in [0, p)
+func (f Fp) Lift(a Matrix) (Matrix, error) {
+    if g, ok := a.Space.(Fp); !ok || g.p != f.p {
+        return Matrix{}, fmt.Errorf("%w: space %v is not GF(%d)", ErrNotSupported, a.Space, f.p)
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        x, err := toFp(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        vals[i] = new(big.Int).SetUint64(f.Uint64(FpElement(x)))
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, Bigint{})
+}
+
+// create a new Matrix in f from int values, reduced modulo p
+func (f Fp) NewMatrixFromInt(rows, cols int, data []int) (Matrix, error) {
+    if data == nil {
+        return NewMatrix(rows, cols, nil, f)
+    }
+    vals := make([]interface{}, len(data))
+    for i, x := range data {
+        vals[i] = f.ElementFromInt(int64(x))
+    }
+    return NewMatrix(rows, cols, vals, f)
+}
+
+func (f Fp) SpaceName() string {return "fp"}
+
+func (f Fp) MarshalSpace() ([]byte, error) {
+    var e encoder
+    e.uvarint(f.p)
+    return e.buf, nil
+}
+
+// the value in [0, p), independent of the Montgomery form
+func (f Fp) MarshalElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    x, err := toFp(a)
+    if err != nil {return nil, err}
+    var e encoder
+    e.uvarint(f.Uint64(FpElement(x)))
+    return e.buf, nil
+}
+
+func (f Fp) UnmarshalElement(b []byte) (interface{}, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    d := decoder{buf: b}
+    x := d.uvarint()
+    if d.err != nil {return nil, d.err}
+    if x >= f.p || len(d.buf) != 0 {
+        return nil, errors.New("malformed GF(p) element")
+    }
+    return f.Element(x), nil
+}
+
+func init() {
+    RegisterSpace("fp", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        p := d.uvarint()
+        if d.err != nil {return nil, d.err}
+        return NewFp(p)
+    })
+}
@@ -0,0 +1,142 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+func TestFp(t *testing.T) {
+    for _, p := range []uint64{3, 101, NTTPrime30, NTTPrime31, NTTPrime62, 1<<63 - 25} {
+        f, err := NewFp(p)
+        if err != nil {t.Fatal(err)}
+        z, err := NewZn(new(big.Int).SetUint64(p))
+        if err != nil {t.Fatal(err)}
+        rng := rand.New(rand.NewSource(int64(p)))
+        for i := 0; i < 200; i += 1 {
+            x, y := rng.Uint64() % p, rng.Uint64() % p
+            if i == 0 {
+                x, y = p - 1, p - 1
+            }
+            a, b := f.Element(x), f.Element(y)
+            bx, by := new(big.Int).SetUint64(x), new(big.Int).SetUint64(y)
+            ops := map[string][2]func(a, b interface{}) (interface{}, error){
+                "add": {f.Add, z.Add},
+                "subtract": {f.Subtract, z.Subtract},
+                "multiply": {f.Multiply, z.Multiply},
+            }
+            for name, op := range ops {
+                r, err := op[0](a, b)
+                if err != nil {t.Fatal(err)}
+                s, err := op[1](bx, by)
+                if err != nil {t.Fatal(err)}
+                if f.Uint64(r.(FpElement)) != s.(*big.Int).Uint64() {
+                    t.Errorf("%s of %d and %d modulo %d: expected %v, got %d", name, x, y, p, s, f.Uint64(r.(FpElement)))
+                }
+            }
+            if x != 0 {
+                inv, err := f.Inverse(a)
+                if err != nil {t.Fatal(err)}
+                one, _ := f.Multiply(a, inv)
+                if f.Uint64(one.(FpElement)) != 1 {
+                    t.Errorf("wrong inverse of %d modulo %d", x, p)
+                }
+            }
+        }
+        if f.Uint64(f.ElementFromInt(-1)) != p - 1 {
+            t.Errorf("-1 modulo %d is %d", p, f.Uint64(f.ElementFromInt(-1)))
+        }
+    }
+    t.Run("invalid modulus", func(t *testing.T) {
+        for _, p := range []uint64{0, 2, 9, 1<<63 + 29} {
+            _, err := NewFp(p)
+            if err == nil {t.Errorf("accepted modulus %d", p)}
+        }
+        f, err := NewFp(7)
+        if err != nil {t.Fatal(err)}
+        _, err = f.Inverse(f.Zero())
+        if !errors.Is(err, ErrNotInvertible) {t.Errorf("expected ErrNotInvertible, got %v", err)}
+    })
+}
+
+func TestRootOfUnity(t *testing.T) {
+    for _, c := range []struct{p, n uint64}{{NTTPrime30, 1 << 23}, {NTTPrime31, 1 << 27}, {NTTPrime62, 1 << 57}, {NTTPrime62, 29 * 8}} {
+        f, err := NewFp(c.p)
+        if err != nil {t.Fatal(err)}
+        w, err := f.RootOfUnity(c.n)
+        if err != nil {t.Fatal(err)}
+        if f.Uint64(f.Exp(w, c.n)) != 1 {
+            t.Errorf("w^%d != 1 modulo %d", c.n, c.p)
+        }
+        for _, q := range primeFactors(c.n) {
+            if f.Uint64(f.Exp(w, c.n / q)) == 1 {
+                t.Errorf("root of unity modulo %d has order below %d", c.p, c.n)
+            }
+        }
+    }
+    f, err := NewFp(NTTPrime30)
+    if err != nil {t.Fatal(err)}
+    _, err = f.RootOfUnity(1 << 24)
+    if err == nil {t.Error("found root of unity of order not dividing p - 1")}
+}
+
+func TestFpMatrix(t *testing.T) {
+    f, err := NewFp(NTTPrime62)
+    if err != nil {t.Fatal(err)}
+    z, err := NewZn(new(big.Int).SetUint64(NTTPrime62))
+    if err != nil {t.Fatal(err)}
+    data := []int{4, -1, 7, 3, 0, 2, -5, 8, 1}
+    a, err := f.NewMatrixFromInt(3, 3, data)
+    if err != nil {t.Fatal(err)}
+    az, err := z.NewMatrixFromInt(3, 3, data)
+    if err != nil {t.Fatal(err)}
+    t.Run("conversion", func(t *testing.T) {
+        b, err := NewMatrixFromInt(3, 3, data)
+        if err != nil {t.Fatal(err)}
+        b, err = f.Reduce(b)
+        if err != nil {t.Fatal(err)}
+        c, err := f.Lift(b)
+        if err != nil {t.Fatal(err)}
+        Compare(c, az, t)
+    })
+    t.Run("matrix operations", func(t *testing.T) {
+        c, err := a.Multiply(a)
+        if err != nil {t.Fatal(err)}
+        c, err = c.Scale(big.NewInt(-3))
+        if err != nil {t.Fatal(err)}
+        c, err = f.Lift(c)
+        if err != nil {t.Fatal(err)}
+        cz, err := az.Multiply(az)
+        if err != nil {t.Fatal(err)}
+        cz, err = cz.Scale(big.NewInt(-3))
+        if err != nil {t.Fatal(err)}
+        Compare(c, cz, t)
+    })
+    t.Run("linear algebra", func(t *testing.T) {
+        inv, err := a.Inverse()
+        if err != nil {t.Fatal(err)}
+        inv, err = f.Lift(inv)
+        if err != nil {t.Fatal(err)}
+        invz, err := az.Inverse()
+        if err != nil {t.Fatal(err)}
+        Compare(inv, invz, t)
+        det, err := a.Determinant()
+        if err != nil {t.Fatal(err)}
+        detz, err := az.Determinant()
+        if err != nil {t.Fatal(err)}
+        if f.Uint64(det.(FpElement)) != detz.(*big.Int).Uint64() {
+            t.Errorf("expected determinant %v, got %d", detz, f.Uint64(det.(FpElement)))
+        }
+    })
+    t.Run("serialization", func(t *testing.T) {
+        enc, err := a.MarshalBinary()
+        if err != nil {t.Fatal(err)}
+        var b Matrix
+        err = b.UnmarshalBinary(enc)
+        if err != nil {t.Fatal(err)}
+        b, err = f.Lift(b)
+        if err != nil {t.Fatal(err)}
+        Compare(b, az, t)
+    })
+}
@@ -1,5 +1,5 @@
 module github.com/ontanj/generic-matrix
 
-go 1.15
+go 1.18
 
 require github.com/niclabs/tcpaillier v0.0.7
@@ -0,0 +1,165 @@
+package genmatrix
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// key material was encoded by an unknown version of the encoding
+var ErrUnsupportedVersion = errors.New("unsupported key encoding version")
+
+// version of the key encodings, the first byte of every encoded key
+const keyEncodingVersion = 1
+
+const (
+    publicKeyPEMType = "DJ PUBLIC KEY"
+    keySharePEMType = "DJ KEY SHARE"
+)
+
+// hash identifying the public key pk
+func (pk DJ_public_key) Fingerprint() [32]byte {
+    return sha256.Sum256(marshalPubKey(pk.PubKey))
+}
+
+// encode pk in a versioned binary form
+func MarshalPublicKey(pk DJ_public_key) ([]byte, error) {
+    if pk.PubKey == nil {
+        return nil, errors.New("public key can't be nil")
+    }
+    return append([]byte{keyEncodingVersion}, marshalPubKey(pk.PubKey)...), nil
+}
+
+// decode a public key encoded by MarshalPublicKey
+func UnmarshalPublicKey(b []byte) (DJ_public_key, error) {
+    b, err := keyVersion(b)
+    if err != nil {return DJ_public_key{}, err}
+    pk, err := unmarshalPubKey(b)
+    if err != nil {return DJ_public_key{}, err}
+    return DJ_public_key{pk}, nil
+}
+
+// encode the key share sk, together with its public key, in a versioned binary form
+func MarshalKeyShare(sk *tcpaillier.KeyShare) ([]byte, error) {
+    if sk == nil || sk.PubKey == nil || sk.Si == nil {
+        return nil, errors.New("key share can't be nil")
+    }
+    e := encoder{buf: []byte{keyEncodingVersion}}
+    e.bytes(marshalPubKey(sk.PubKey))
+    e.buf = append(e.buf, sk.Index)
+    e.bigint(sk.Si)
+    return e.buf, nil
+}
+
+// decode a key share encoded by MarshalKeyShare
+// the share is checked against the verification key of its public key,
+// use VerifyKeyShare to also check that it belongs to a known public key
+func UnmarshalKeyShare(b []byte) (*tcpaillier.KeyShare, error) {
+    b, err := keyVersion(b)
+    if err != nil {return nil, err}
+    d := decoder{buf: b}
+    pk_bytes := d.bytes()
+    if d.err != nil {return nil, d.err}
+    pk, err := unmarshalPubKey(pk_bytes)
+    if err != nil {return nil, err}
+    if len(d.buf) < 1 {
+        return nil, errTruncated
+    }
+    sk := &tcpaillier.KeyShare{PubKey: pk, Index: d.buf[0]}
+    d.buf = d.buf[1:]
+    sk.Si = d.bigint()
+    if d.err != nil {return nil, d.err}
+    if sk.Si == nil {
+        return nil, errors.New("malformed key share")
+    }
+    if len(d.buf) != 0 {
+        return nil, fmt.Errorf("%d trailing bytes after key share", len(d.buf))
+    }
+    err = VerifyKeyShare(DJ_public_key{pk}, sk)
+    if err != nil {return nil, err}
+    return sk, nil
+}
+
+// check that sk is the key share with its index of the public key pk
+func VerifyKeyShare(pk DJ_public_key, sk *tcpaillier.KeyShare) error {
+    if pk.PubKey == nil || sk == nil || sk.PubKey == nil || sk.Si == nil {
+        return errors.New("keys can't be nil")
+    }
+    if !bytes.Equal(marshalPubKey(pk.PubKey), marshalPubKey(sk.PubKey)) {
+        return fmt.Errorf("%w: key share %d belongs to another public key", ErrKeyMismatch, sk.Index)
+    }
+    if sk.Index < 1 || int(sk.Index) > len(pk.Vi) {
+        return fmt.Errorf("%w: no key share with index %d", ErrKeyMismatch, sk.Index)
+    }
+    // the verification key of share i is V^(delta * s_i)
+    n_to_s_plus_one := new(big.Int).Exp(pk.N, big.NewInt(int64(pk.S)+1), nil)
+    vi := new(big.Int).Mul(sk.Si, pk.Delta)
+    vi.Exp(pk.V, vi, n_to_s_plus_one)
+    if vi.Cmp(pk.Vi[sk.Index-1]) != 0 {
+        return fmt.Errorf("%w: key share %d does not match its verification key", ErrKeyMismatch, sk.Index)
+    }
+    return nil
+}
+
+// encode pk as a PEM block
+func PublicKeyToPEM(pk DJ_public_key) ([]byte, error) {
+    b, err := MarshalPublicKey(pk)
+    if err != nil {return nil, err}
+    return pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: b}), nil
+}
+
+// decode a public key from the first PEM block in b
+func PublicKeyFromPEM(b []byte) (DJ_public_key, error) {
+    block, err := pemBlock(b, publicKeyPEMType)
+    if err != nil {return DJ_public_key{}, err}
+    return UnmarshalPublicKey(block.Bytes)
+}
+
+// encode sk as a PEM block, with the fingerprint of its public key as header
+func KeyShareToPEM(sk *tcpaillier.KeyShare) ([]byte, error) {
+    b, err := MarshalKeyShare(sk)
+    if err != nil {return nil, err}
+    fingerprint := DJ_public_key{sk.PubKey}.Fingerprint()
+    return pem.EncodeToMemory(&pem.Block{
+        Type: keySharePEMType,
+        Headers: map[string]string{
+            "Index": fmt.Sprint(sk.Index),
+            "Public-Key": hex.EncodeToString(fingerprint[:]),
+        },
+        Bytes: b,
+    }), nil
+}
+
+// decode a key share from the first PEM block in b
+func KeyShareFromPEM(b []byte) (*tcpaillier.KeyShare, error) {
+    block, err := pemBlock(b, keySharePEMType)
+    if err != nil {return nil, err}
+    return UnmarshalKeyShare(block.Bytes)
+}
+
+// strip and check the version byte of an encoded key
+func keyVersion(b []byte) ([]byte, error) {
+    if len(b) == 0 {
+        return nil, errTruncated
+    }
+    if b[0] != keyEncodingVersion {
+        return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b[0])
+    }
+    return b[1:], nil
+}
+
+func pemBlock(b []byte, block_type string) (*pem.Block, error) {
+    block, _ := pem.Decode(b)
+    if block == nil {
+        return nil, errors.New("no PEM block found")
+    }
+    if block.Type != block_type {
+        return nil, fmt.Errorf("PEM block is %q, expected %q", block.Type, block_type)
+    }
+    return block, nil
+}
@@ -0,0 +1,97 @@
+package genmatrix
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "testing"
+    "github.com/niclabs/tcpaillier"
+)
+
+func TestKeySerialization(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+    if err != nil {t.Fatal(err)}
+    t.Run("binary", func(t *testing.T) {
+        b, err := MarshalPublicKey(cs)
+        if err != nil {t.Fatal(err)}
+        pk, err := UnmarshalPublicKey(b)
+        if err != nil {t.Fatal(err)}
+        if pk.Fingerprint() != cs.Fingerprint() {
+            t.Error("public key changed in serialization")
+        }
+        sks := make([]*tcpaillier.KeyShare, len(djsks))
+        for i, sk := range djsks {
+            b, err := MarshalKeyShare(sk)
+            if err != nil {t.Fatal(err)}
+            sks[i], err = UnmarshalKeyShare(b)
+            if err != nil {t.Fatal(err)}
+            err = VerifyKeyShare(pk, sks[i])
+            if err != nil {t.Error(err)}
+        }
+        // keys on both sides must interoperate
+        ae, err := EncryptMatrix(a, pk)
+        if err != nil {t.Fatal(err)}
+        ad, err := DecryptMatrix(ae, sks)
+        if err != nil {t.Fatal(err)}
+        Compare(ad, a, t)
+    })
+    t.Run("pem", func(t *testing.T) {
+        b, err := PublicKeyToPEM(cs)
+        if err != nil {t.Fatal(err)}
+        if !bytes.HasPrefix(b, []byte("-----BEGIN DJ PUBLIC KEY-----")) {
+            t.Errorf("unexpected PEM encoding:\n%s", b)
+        }
+        pk, err := PublicKeyFromPEM(b)
+        if err != nil {t.Fatal(err)}
+        sks := make([]*tcpaillier.KeyShare, len(djsks))
+        for i, sk := range djsks {
+            b, err := KeyShareToPEM(sk)
+            if err != nil {t.Fatal(err)}
+            sks[i], err = KeyShareFromPEM(b)
+            if err != nil {t.Fatal(err)}
+        }
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        ad, err := DecryptMatrix(ae, sks)
+        if err != nil {t.Fatal(err)}
+        Compare(ad, a, t)
+        _, err = KeyShareFromPEM(b)
+        if err == nil {t.Error("decoded public key as key share")}
+        if pk.Fingerprint() != cs.Fingerprint() {
+            t.Error("public key changed in serialization")
+        }
+    })
+    t.Run("share of other key", func(t *testing.T) {
+        other, _, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        err = VerifyKeyShare(other, djsks[0])
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+    })
+    t.Run("tampered share", func(t *testing.T) {
+        sk := *djsks[0]
+        sk.Si = new(big.Int).Add(sk.Si, big.NewInt(1))
+        err := VerifyKeyShare(cs, &sk)
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+        b, err := MarshalKeyShare(&sk)
+        if err != nil {t.Fatal(err)}
+        _, err = UnmarshalKeyShare(b)
+        if !errors.Is(err, ErrKeyMismatch) {t.Errorf("expected ErrKeyMismatch, got %v", err)}
+    })
+    t.Run("version", func(t *testing.T) {
+        b, err := MarshalPublicKey(cs)
+        if err != nil {t.Fatal(err)}
+        b[0] = keyEncodingVersion + 1
+        _, err = UnmarshalPublicKey(b)
+        if !errors.Is(err, ErrUnsupportedVersion) {t.Errorf("expected ErrUnsupportedVersion, got %v", err)}
+    })
+    t.Run("truncated", func(t *testing.T) {
+        b, err := MarshalKeyShare(djsks[1])
+        if err != nil {t.Fatal(err)}
+        for _, l := range []int{0, 1, len(b) / 2, len(b) - 1} {
+            _, err = UnmarshalKeyShare(b[:l])
+            if err == nil {t.Errorf("accepted key share truncated to %d bytes", l)}
+        }
+    })
+}
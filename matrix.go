@@ -1,9 +1,14 @@
 package genmatrix
 
 import (
+    "context"
+    "errors"
     "fmt"
 )
 
+// the space of a matrix lacks a capability needed by an operation
+var ErrNotSupported = errors.New("operation not supported by space")
+
 type Matrix struct {
     values []interface{}
     Rows, Cols int
@@ -50,103 +55,103 @@ func (m Matrix) Set(row, col int, value interface{}) error {
 // multiply a * b
 // also handles multiplication of scalar * non-scalar matrices and vice versa
 // if a and b are non-scalar in different spaces, the space of a is used
-func (a Matrix) Multiply(b Matrix) (c Matrix, err error) {
+// the elements of the product are computed in parallel, see WithWorkers
+func (a Matrix) Multiply(b Matrix, opts ...Option) (c Matrix, err error) {
     if a.Cols != b.Rows {
         err = fmt.Errorf("matrices a and b are not compatible")
         return
     }
     cRows, cCols := a.Rows, b.Cols
     values := make([]interface{}, cRows*cCols)
-    var r, a_val, b_val interface{}
-    var space Space
-    for i := 0; i < cRows; i += 1 {
-        for j := 0; j < cCols; j += 1 {
-            var sum interface{}
-            for k := 0; k < a.Cols; k += 1 {
-                a_val, err = a.At(i, k)
-                b_val, err = b.At(k, j)
+    space := a.Space
+    if a.Space.Scalarspace() {
+        space = b.Space
+    }
+    err = parallelFor(len(values), newOptions(opts), func(e int) (err error) {
+        i, j := e / cCols, e % cCols
+        var sum, r interface{}
+        for k := 0; k < a.Cols; k += 1 {
+            a_val, b_val := a.values[i*a.Cols+k], b.values[k*b.Cols+j]
+            if a.Space.Scalarspace() {
+                r, err = b.Space.Scale(b_val, a_val)
+                if err != nil {return}
+            } else if b.Space.Scalarspace() {
+                r, err = a.Space.Scale(a_val, b_val)
+                if err != nil {return}
+            } else {
+                r, err = a.Space.Multiply(a_val, b_val)
+                if err != nil {return}
+            }
+            if sum == nil {
+                sum = r
+            } else {
+                sum, err = space.Add(r, sum)
                 if err != nil {return}
-                if a.Space.Scalarspace() {
-                    space = b.Space
-                    r, err = b.Space.Scale(b_val, a_val)
-                    if err != nil {return a, err}
-                    if sum == nil {
-                        sum = r
-                    } else {
-                        sum, err = b.Space.Add(r, sum)
-                        if err != nil {return a, err}
-                    }
-                } else {
-                    space = a.Space
-                    if b.Space.Scalarspace() {
-                        r, err = a.Space.Scale(a_val, b_val)
-                        if err != nil {return a, err}
-                    } else {
-                        r, err = a.Space.Multiply(a_val, b_val)
-                        if err != nil {return a, err}
-                    }
-                    if sum == nil {
-                        sum = r
-                    } else {
-                        sum, err = a.Space.Add(r, sum)
-                        if err != nil {return a, err}
-                    }
-                }
             }
-            values[i*cCols+j] = sum
-            sum = nil
         }
-    }
+        // an empty sum is zero, if the space knows its zero
+        if z, ok := space.(Zeroer); ok && sum == nil {
+            sum = z.Zero()
+        }
+        values[e] = sum
+        return
+    })
+    if err != nil {return a, err}
     return NewMatrix(cRows, cCols, values, space)
 }
 
+// Multiply, stopping with ctx.Err() once ctx is done
+func (a Matrix) MultiplyContext(ctx context.Context, b Matrix, opts ...Option) (Matrix, error) {
+    return a.Multiply(b, withContext(ctx, opts)...)
+}
+
 // multiplication of a by a scalar
 // assumes matrix and factor is in same space, otherwise use Scale
-func (a Matrix) MultiplyScalar(scalar interface{}) (Matrix, error) {
-    return scalarMultiplication(a.Space.Multiply, a, scalar)
+func (a Matrix) MultiplyScalar(scalar interface{}, opts ...Option) (Matrix, error) {
+    return scalarMultiplication(a.Space.Multiply, a, scalar, opts)
 }
 
 // scale a according to scalar
 // to be used if factor is in a scalar space wile a is not
-func (a Matrix) Scale(factor interface{}) (Matrix, error) {
-    return scalarMultiplication(a.Space.Scale, a, factor)
+func (a Matrix) Scale(factor interface{}, opts ...Option) (Matrix, error) {
+    return scalarMultiplication(a.Space.Scale, a, factor, opts)
 }
 
-func scalarMultiplication(mulfunc func(interface{}, interface{}) (interface{}, error), a Matrix, b interface{}) (Matrix, error) {
-    c_vals := make([]interface{}, len(a.values))
-    var err error
-    for i := range c_vals {
-        c_vals[i], err = mulfunc(a.values[i], b)
-        if err != nil {return a, err}
-    }
-    return NewMatrix(a.Rows, a.Cols, c_vals, a.Space)
+// Scale, stopping with ctx.Err() once ctx is done
+func (a Matrix) ScaleContext(ctx context.Context, factor interface{}, opts ...Option) (Matrix, error) {
+    return a.Scale(factor, withContext(ctx, opts)...)
+}
+
+func scalarMultiplication(mulfunc func(interface{}, interface{}) (interface{}, error), a Matrix, b interface{}, opts []Option) (Matrix, error) {
+    return a.apply(func(v interface{}) (interface{}, error) {return mulfunc(v, b)}, opts)
 }
 
 // matrix addition
-func (a Matrix) Add(b Matrix) (c Matrix, err error) {
+func (a Matrix) Add(b Matrix, opts ...Option) (c Matrix, err error) {
     if a.Rows != b.Rows || a.Cols != b.Cols {
         err = fmt.Errorf("dimension mismatch in addition: %d x %d != %d x %d", a.Rows, a.Cols, b.Rows, b.Cols)
         return
     }
-    c_vals := make([]interface{}, len(a.values))
-    for i := range c_vals {
-        c_vals[i], err = a.Space.Add(a.values[i], b.values[i])
-        if err != nil {return a, err}
-    }
-    return NewMatrix(a.Rows, a.Cols, c_vals, a.Space)
+    return elementwise(a.Space.Add, a, b, opts)
 }
 
 // matrix subtraction
-func (a Matrix) Subtract(b Matrix) (c Matrix, err error) {
+func (a Matrix) Subtract(b Matrix, opts ...Option) (c Matrix, err error) {
     if a.Rows != b.Rows || a.Cols != b.Cols {
         err = fmt.Errorf("dimension mismatch in subtraction: %d x %d != %d x %d", a.Rows, a.Cols, b.Rows, b.Cols)
         return
     }
+    return elementwise(a.Space.Subtract, a, b, opts)
+}
+
+// apply f to the elements of a and b pairwise, in parallel
+func elementwise(f func(interface{}, interface{}) (interface{}, error), a, b Matrix, opts []Option) (Matrix, error) {
     c_vals := make([]interface{}, len(a.values))
-    for i := range c_vals {
-        c_vals[i], err = a.Space.Subtract(a.values[i], b.values[i])
-        if err != nil {return a, err}
-    }
+    err := parallelFor(len(c_vals), newOptions(opts), func(i int) (err error) {
+        c_vals[i], err = f(a.values[i], b.values[i])
+        return
+    })
+    if err != nil {return a, err}
     return NewMatrix(a.Rows, a.Cols, c_vals, a.Space)
 }
 
@@ -164,22 +169,182 @@ func (a Matrix) Concatenate(b Matrix) (Matrix, error) {
 }
 
 // create a new matrix from last k columns of a
-func (a Matrix) CropHorizontally(k int) Matrix {
-    vals := make([]interface{}, 0, k*a.Rows)
-    d := a.Cols - k
-    for i := 0; i < a.Rows; i += 1 {
-        vals = append(vals, a.values[i*a.Cols+d:(i+1)*a.Cols]...)
+func (a Matrix) CropHorizontally(k int) (Matrix, error) {
+    if k < 0 || k > a.Cols {
+        return Matrix{}, fmt.Errorf("can't keep %d of %d columns", k, a.Cols)
     }
-    c, _ := NewMatrix(a.Rows, k, vals, a.Space)
-    return c
+    return a.Submatrix(0, a.Rows, a.Cols - k, a.Cols)
 }
 
 // apply function f to all matrix elements
-func (a Matrix) Apply(f func(interface{}) (interface{}, error)) (b Matrix, err error) {
+// f is called from a single goroutine unless more workers are requested with
+// WithWorkers, e.g. WithWorkers(0) for the default, in which case f is called
+// in parallel and must be safe for concurrent use
+func (a Matrix) Apply(f func(interface{}) (interface{}, error), opts ...Option) (b Matrix, err error) {
+    return a.apply(f, append([]Option{WithWorkers(1)}, opts...))
+}
+
+// Apply in parallel by default, for functions of the space, which are safe for concurrent use
+func (a Matrix) apply(f func(interface{}) (interface{}, error), opts []Option) (b Matrix, err error) {
     b_vals := make([]interface{}, len(a.values))
-    for i, v := range a.values {
-        b_vals[i], err = f(v)
-        if err != nil {return}
-    }
+    err = parallelFor(len(b_vals), newOptions(opts), func(i int) (err error) {
+        b_vals[i], err = f(a.values[i])
+        return
+    })
+    if err != nil {return}
     return NewMatrix(a.Rows, a.Cols, b_vals, a.Space)
-}
\ No newline at end of file
+}
+
+// Apply, stopping with ctx.Err() once ctx is done
+func (a Matrix) ApplyContext(ctx context.Context, f func(interface{}) (interface{}, error), opts ...Option) (Matrix, error) {
+    return a.Apply(f, withContext(ctx, opts)...)
+}
+
+// transpose of a
+func (a Matrix) Transpose() Matrix {
+    vals := make([]interface{}, len(a.values))
+    for i := 0; i < a.Rows; i += 1 {
+        for j := 0; j < a.Cols; j += 1 {
+            vals[j*a.Rows+i] = a.values[i*a.Cols+j]
+        }
+    }
+    b, _ := NewMatrix(a.Cols, a.Rows, vals, a.Space)
+    return b
+}
+
+// concatenate matrices with a above b
+func (a Matrix) ConcatenateVertically(b Matrix) (Matrix, error) {
+    if a.Cols != b.Cols {
+        return Matrix{}, fmt.Errorf("matrices not compatible for vertical concatenation, a has %d columns while b has %d columns", a.Cols, b.Cols)
+    }
+    vals := make([]interface{}, 0, (a.Rows + b.Rows) * a.Cols)
+    vals = append(vals, a.values...)
+    vals = append(vals, b.values...)
+    return NewMatrix(a.Rows + b.Rows, a.Cols, vals, a.Space)
+}
+
+// create a new matrix from rows row0 to row1-1 and columns col0 to col1-1 of a
+func (a Matrix) Submatrix(row0, row1, col0, col1 int) (Matrix, error) {
+    if row0 < 0 || row1 > a.Rows || row0 > row1 {
+        return Matrix{}, fmt.Errorf("row range [%d, %d) out of bounds for %d rows", row0, row1, a.Rows)
+    }
+    if col0 < 0 || col1 > a.Cols || col0 > col1 {
+        return Matrix{}, fmt.Errorf("column range [%d, %d) out of bounds for %d columns", col0, col1, a.Cols)
+    }
+    vals := make([]interface{}, 0, (row1 - row0) * (col1 - col0))
+    for i := row0; i < row1; i += 1 {
+        vals = append(vals, a.values[i*a.Cols+col0:i*a.Cols+col1]...)
+    }
+    return NewMatrix(row1 - row0, col1 - col0, vals, a.Space)
+}
+
+// create a new matrix from the rows of a with the given indices, in that order
+func (a Matrix) SelectRows(rows []int) (Matrix, error) {
+    vals := make([]interface{}, 0, len(rows) * a.Cols)
+    for _, i := range rows {
+        if i < 0 || i >= a.Rows {
+            return Matrix{}, fmt.Errorf("row %d out of bounds for %d rows", i, a.Rows)
+        }
+        vals = append(vals, a.values[i*a.Cols:(i+1)*a.Cols]...)
+    }
+    return NewMatrix(len(rows), a.Cols, vals, a.Space)
+}
+
+// create a new matrix from the columns of a with the given indices, in that order
+func (a Matrix) SelectCols(cols []int) (Matrix, error) {
+    for _, j := range cols {
+        if j < 0 || j >= a.Cols {
+            return Matrix{}, fmt.Errorf("column %d out of bounds for %d columns", j, a.Cols)
+        }
+    }
+    vals := make([]interface{}, 0, a.Rows * len(cols))
+    for i := 0; i < a.Rows; i += 1 {
+        for _, j := range cols {
+            vals = append(vals, a.values[i*a.Cols+j])
+        }
+    }
+    return NewMatrix(a.Rows, len(cols), vals, a.Space)
+}
+
+// create a new matrix from a without row i
+func (a Matrix) RemoveRow(i int) (Matrix, error) {
+    if i < 0 || i >= a.Rows {
+        return Matrix{}, fmt.Errorf("row %d out of bounds for %d rows", i, a.Rows)
+    }
+    return a.SelectRows(indicesWithout(a.Rows, i))
+}
+
+// create a new matrix from a without column j
+func (a Matrix) RemoveCol(j int) (Matrix, error) {
+    if j < 0 || j >= a.Cols {
+        return Matrix{}, fmt.Errorf("column %d out of bounds for %d columns", j, a.Cols)
+    }
+    return a.SelectCols(indicesWithout(a.Cols, j))
+}
+
+// the indices 0 to n-1, except skip
+func indicesWithout(n, skip int) []int {
+    indices := make([]int, 0, n-1)
+    for i := 0; i < n; i += 1 {
+        if i != skip {
+            indices = append(indices, i)
+        }
+    }
+    return indices
+}
+
+// create a rows x cols matrix of zeros, requires space to be a Zeroer
+func Zeros(rows, cols int, space Space) (Matrix, error) {
+    z, ok := space.(Zeroer)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: %T has no zero", ErrNotSupported, space)
+    }
+    vals := make([]interface{}, rows*cols)
+    for i := range vals {
+        vals[i] = z.Zero()
+    }
+    return NewMatrix(rows, cols, vals, space)
+}
+
+// create the n x n identity matrix, requires space to be a Zeroer and a Oner
+func Identity(n int, space Space) (Matrix, error) {
+    o, ok := space.(Oner)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: %T has no one", ErrNotSupported, space)
+    }
+    m, err := Zeros(n, n, space)
+    if err != nil {return Matrix{}, err}
+    for i := 0; i < n; i += 1 {
+        m.values[i*n+i] = o.One()
+    }
+    return m, nil
+}
+
+// return true if a and b have the same size and elements
+// requires the space of a to be an Equaler
+func (a Matrix) Equal(b Matrix) (bool, error) {
+    e, ok := a.Space.(Equaler)
+    if !ok {
+        return false, fmt.Errorf("%w: %T can't compare elements", ErrNotSupported, a.Space)
+    }
+    if a.Rows != b.Rows || a.Cols != b.Cols {
+        return false, nil
+    }
+    for i := range a.values {
+        eq, err := e.Equal(a.values[i], b.values[i])
+        if err != nil {return false, err}
+        if !eq {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+// additive inverse of a, requires the space of a to be a Negator
+func (a Matrix) Negate() (Matrix, error) {
+    n, ok := a.Space.(Negator)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: %T can't negate elements", ErrNotSupported, a.Space)
+    }
+    return a.apply(n.Negate, nil)
+}
@@ -0,0 +1,203 @@
+package genmatrix
+
+import (
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// PackedMatrix is an encrypted matrix with several entries per ciphertext
+// each row is split into ciphertexts of Slots consecutive entries, the j:th
+// entry of a ciphertext taking the bits from j * SlotBits of the plaintext
+// entries are signed and bounded in absolute value by Bound, which is tracked
+// through the operations and must stay below 2^(SlotBits-1)
+type PackedMatrix struct {
+    Rows, Cols int
+    SlotBits uint
+    Slots int
+    Bound *big.Int
+    // encrypted matrix of Rows x ceil(Cols / Slots) packed ciphertexts
+    cipher Matrix
+}
+
+// number of slots of slot_bits bits fitting the signed plaintext range of pk
+func packedSlots(pk DJ_public_key, slot_bits uint) int {
+    // sums of entries below 2^(slot_bits-1) stay below 2^(slots*slot_bits-1) <= MaxSigned
+    return (pk.Cache().NToS.BitLen() - 1) / int(slot_bits)
+}
+
+// encrypt the plaintext Bigint matrix a with pk, packing the entries of each
+// row into slots of slot_bits bits
+// choose slot_bits with room for the growth of the entries in later operations
+func PackMatrix(a Matrix, pk DJ_public_key, slot_bits uint, opts ...Option) (PackedMatrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return PackedMatrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    if pk.PubKey == nil {
+        return PackedMatrix{}, fmt.Errorf("public key can't be nil")
+    }
+    if slot_bits < 2 {
+        return PackedMatrix{}, fmt.Errorf("slots of %d bits can't hold signed entries", slot_bits)
+    }
+    slots := packedSlots(pk, slot_bits)
+    if slots < 1 {
+        return PackedMatrix{}, fmt.Errorf("%w: slots of %d bits exceed the plaintext space", ErrPlaintextOverflow, slot_bits)
+    }
+    p := PackedMatrix{Rows: a.Rows, Cols: a.Cols, SlotBits: slot_bits, Slots: slots, Bound: new(big.Int)}
+    for i, v := range a.values {
+        x, err := toBigint(v)
+        if err != nil {return PackedMatrix{}, elementError(a, i, err)}
+        if x.CmpAbs(p.Bound) > 0 {
+            p.Bound.Abs(x)
+        }
+    }
+    err := p.checkBound(p.Bound)
+    if err != nil {return PackedMatrix{}, err}
+    per_row := p.perRow()
+    packed := make([]interface{}, a.Rows*per_row)
+    for i := range packed {
+        row, first := i / per_row, (i % per_row) * slots
+        last := first + slots
+        if last > a.Cols {
+            last = a.Cols
+        }
+        sum := new(big.Int)
+        for col := last - 1; col >= first; col -= 1 {
+            sum.Lsh(sum, slot_bits).Add(sum, a.values[row*a.Cols+col].(*big.Int))
+        }
+        packed[i] = sum
+    }
+    plain, err := NewMatrix(a.Rows, per_row, packed, Bigint{})
+    if err != nil {return PackedMatrix{}, err}
+    p.cipher, err = EncryptMatrix(plain, pk, opts...)
+    if err != nil {return PackedMatrix{}, err}
+    return p, nil
+}
+
+// number of ciphertexts per row
+func (p PackedMatrix) perRow() int {
+    return (p.Cols + p.Slots - 1) / p.Slots
+}
+
+// fail if entries bounded by bound don't fit the slots
+func (p PackedMatrix) checkBound(bound *big.Int) error {
+    if bound.BitLen() >= int(p.SlotBits) {
+        return fmt.Errorf("%w: entries of %d bits in slots of %d bits", ErrPlaintextOverflow, bound.BitLen(), p.SlotBits)
+    }
+    return nil
+}
+
+// the packed ciphertexts as an encrypted matrix, e.g. for partial decryption,
+// whose decryption is unpacked with Unpack
+func (p PackedMatrix) Ciphertexts() Matrix {
+    return p.cipher
+}
+
+func (p PackedMatrix) withCipher(cipher Matrix, bound *big.Int) PackedMatrix {
+    p.cipher, p.Bound = cipher, bound
+    return p
+}
+
+// homomorphic addition of packed matrices of the same size and slot layout
+func (p PackedMatrix) Add(q PackedMatrix, opts ...Option) (PackedMatrix, error) {
+    if p.Rows != q.Rows || p.Cols != q.Cols {
+        return PackedMatrix{}, fmt.Errorf("dimension mismatch in addition: %d x %d != %d x %d", p.Rows, p.Cols, q.Rows, q.Cols)
+    }
+    if p.SlotBits != q.SlotBits || p.Slots != q.Slots {
+        return PackedMatrix{}, fmt.Errorf("slot mismatch in addition: %d slots of %d bits != %d slots of %d bits", p.Slots, p.SlotBits, q.Slots, q.SlotBits)
+    }
+    pk, err := encryptionKey(p.cipher)
+    if err != nil {return PackedMatrix{}, err}
+    q_pk, err := encryptionKey(q.cipher)
+    if err != nil {return PackedMatrix{}, err}
+    if !samePubKey(pk.PubKey, q_pk.PubKey) {
+        return PackedMatrix{}, ErrKeyMismatch
+    }
+    bound := new(big.Int).Add(p.Bound, q.Bound)
+    err = p.checkBound(bound)
+    if err != nil {return PackedMatrix{}, err}
+    sum, err := p.cipher.Add(q.cipher, opts...)
+    if err != nil {return PackedMatrix{}, err}
+    return p.withCipher(sum, bound), nil
+}
+
+// scale every entry of p by a plaintext factor
+func (p PackedMatrix) Scale(factor *big.Int, opts ...Option) (PackedMatrix, error) {
+    if factor == nil {
+        return PackedMatrix{}, fmt.Errorf("factor can't be nil")
+    }
+    bound := new(big.Int).Mul(p.Bound, new(big.Int).Abs(factor))
+    err := p.checkBound(bound)
+    if err != nil {return PackedMatrix{}, err}
+    scaled, err := p.cipher.Scale(factor, opts...)
+    if err != nil {return PackedMatrix{}, err}
+    return p.withCipher(scaled, bound), nil
+}
+
+// multiply the plaintext Bigint 1 x p.Rows matrix v by p, giving the packed 1 x p.Cols product
+// a product with a column vector, a * x, is computed by packing a.Transpose()
+// and multiplying by x.Transpose(), giving (a * x).Transpose()
+func (p PackedMatrix) LeftMultiply(v Matrix, opts ...Option) (PackedMatrix, error) {
+    if _, ok := v.Space.(Bigint); !ok {
+        return PackedMatrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, v.Space)
+    }
+    if v.Rows != 1 || v.Cols != p.Rows {
+        return PackedMatrix{}, fmt.Errorf("matrices a and b are not compatible")
+    }
+    bound := new(big.Int)
+    for i, x := range v.values {
+        b, err := toBigint(x)
+        if err != nil {return PackedMatrix{}, elementError(v, i, err)}
+        bound.Add(bound, new(big.Int).Mul(p.Bound, new(big.Int).Abs(b)))
+    }
+    err := p.checkBound(bound)
+    if err != nil {return PackedMatrix{}, err}
+    product, err := v.Multiply(p.cipher, opts...)
+    if err != nil {return PackedMatrix{}, err}
+    p.Rows = 1
+    return p.withCipher(product, bound), nil
+}
+
+// split the decryption of p.Ciphertexts() into the plaintext Bigint matrix of the entries
+func (p PackedMatrix) Unpack(plain Matrix) (Matrix, error) {
+    if _, ok := plain.Space.(Bigint); !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, plain.Space)
+    }
+    per_row := p.perRow()
+    if plain.Rows != p.Rows || plain.Cols != per_row {
+        return Matrix{}, fmt.Errorf("dimension mismatch in unpacking: %d x %d != %d x %d", plain.Rows, plain.Cols, p.Rows, per_row)
+    }
+    modulus := new(big.Int).Lsh(big.NewInt(1), p.SlotBits)
+    half := new(big.Int).Rsh(modulus, 1)
+    vals := make([]interface{}, p.Rows*p.Cols)
+    for i, v := range plain.values {
+        sum, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(plain, i, err)}
+        sum = new(big.Int).Set(sum)
+        row, first := i / per_row, (i % per_row) * p.Slots
+        for col := first; col < first + p.Slots && col < p.Cols; col += 1 {
+            // the entry is the residue of the sum closest to zero
+            x := new(big.Int).Mod(sum, modulus)
+            if x.Cmp(half) >= 0 {
+                x.Sub(x, modulus)
+            }
+            vals[row*p.Cols+col] = x
+            sum.Sub(sum, x).Rsh(sum, p.SlotBits)
+        }
+    }
+    return NewMatrix(p.Rows, p.Cols, vals, Bigint{})
+}
+
+// decrypt p using at least threshold many key shares and unpack the entries
+func DecryptPacked(p PackedMatrix, sks []*tcpaillier.KeyShare, opts ...Option) (Matrix, error) {
+    plain, err := DecryptMatrix(p.cipher, sks, opts...)
+    if err != nil {return Matrix{}, err}
+    return p.Unpack(plain)
+}
+
+// rerandomize the ciphertexts of p, see Rerandomize
+func (p PackedMatrix) Rerandomize(opts ...Option) (PackedMatrix, error) {
+    cipher, err := Rerandomize(p.cipher, opts...)
+    if err != nil {return PackedMatrix{}, err}
+    return p.withCipher(cipher, p.Bound), nil
+}
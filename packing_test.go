@@ -0,0 +1,82 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestPacking(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 9, []int{
+        1, -2, 3, 0, 5, -600, 7, 8, 9,
+        -10, 11, 12, 13, -14, 15, 16, 17, 1000,
+    })
+    if err != nil {t.Fatal(err)}
+    ap, err := PackMatrix(a, cs, 32)
+    if err != nil {t.Fatal(err)}
+    if ap.Slots < 2 || ap.Ciphertexts().Cols >= a.Cols {
+        t.Errorf("expected several entries per ciphertext, got %d slots", ap.Slots)
+    }
+    t.Run("round trip", func(t *testing.T) {
+        b, err := DecryptPacked(ap, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(b, a, t)
+    })
+    t.Run("add and scale", func(t *testing.T) {
+        sum, err := ap.Add(ap)
+        if err != nil {t.Fatal(err)}
+        sum, err = sum.Scale(big.NewInt(-3))
+        if err != nil {t.Fatal(err)}
+        correct, err := a.Scale(big.NewInt(-6))
+        if err != nil {t.Fatal(err)}
+        b, err := DecryptPacked(sum, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(b, correct, t)
+        if sum.Bound.Cmp(big.NewInt(6000)) != 0 {
+            t.Errorf("expected bound 6000, got %v", sum.Bound)
+        }
+    })
+    t.Run("matrix-vector product", func(t *testing.T) {
+        x, err := NewMatrixFromInt(9, 1, []int{1, 2, 3, 4, 5, 6, 7, 8, -9})
+        if err != nil {t.Fatal(err)}
+        correct, err := a.Multiply(x)
+        if err != nil {t.Fatal(err)}
+        at, err := PackMatrix(a.Transpose(), cs, 32)
+        if err != nil {t.Fatal(err)}
+        product, err := at.LeftMultiply(x.Transpose())
+        if err != nil {t.Fatal(err)}
+        b, err := DecryptPacked(product, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(b, correct.Transpose(), t)
+    })
+    t.Run("distributed decryption", func(t *testing.T) {
+        parts := make([]PartialDecryption, len(djsks))
+        for i, sk := range djsks {
+            parts[i], err = PartialDecryptMatrix(ap.Ciphertexts(), sk)
+            if err != nil {t.Fatal(err)}
+        }
+        plain, err := CombinePartialDecryptions(cs, parts)
+        if err != nil {t.Fatal(err)}
+        b, err := ap.Unpack(plain)
+        if err != nil {t.Fatal(err)}
+        Compare(b, a, t)
+    })
+    t.Run("overflow", func(t *testing.T) {
+        _, err := PackMatrix(a, cs, 10)
+        if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+        _, err = ap.Scale(big.NewInt(1 << 22))
+        if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+        _, err = PackMatrix(a, cs, 1024)
+        if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+    })
+    t.Run("mismatch", func(t *testing.T) {
+        bp, err := PackMatrix(a, cs, 16)
+        if err != nil {t.Fatal(err)}
+        _, err = ap.Add(bp)
+        if err == nil {t.Error("no error on adding different slot layouts")}
+        _, err = ap.LeftMultiply(a)
+        if err == nil {t.Error("no error on incompatible product")}
+    })
+}
@@ -0,0 +1,118 @@
+package genmatrix
+
+import (
+    "context"
+    "runtime"
+    "sync"
+    "sync/atomic"
+)
+
+type options struct {
+    workers int
+    ctx context.Context
+    progress func(done, total int)
+}
+
+// option for matrix operations that run in parallel
+type Option func(*options)
+
+// run on at most n goroutines, n < 1 means the default of runtime.GOMAXPROCS
+func WithWorkers(n int) Option {
+    return func(o *options) {o.workers = n}
+}
+
+// report progress by calling f with the number of completed and total elements
+// calls are never concurrent, and are made from the goroutines doing the work
+func WithProgress(f func(done, total int)) Option {
+    return func(o *options) {o.progress = f}
+}
+
+// stop starting new work once ctx is done
+func withContext(ctx context.Context, opts []Option) []Option {
+    return append(opts[:len(opts):len(opts)], func(o *options) {o.ctx = ctx})
+}
+
+// report progress of one phase out of phases many, each of n elements, as overall progress
+func withPhase(o options, phase, phases, n int) Option {
+    return func(p *options) {
+        if o.progress != nil {
+            p.progress = func(done, _ int) {o.progress(phase*n + done, phases*n)}
+        }
+    }
+}
+
+func newOptions(opts []Option) options {
+    o := options{}
+    for _, opt := range opts {
+        opt(&o)
+    }
+    if o.workers < 1 {
+        o.workers = runtime.GOMAXPROCS(0)
+    }
+    if o.ctx == nil {
+        o.ctx = context.Background()
+    }
+    return o
+}
+
+// call f(i) for every i in [0, n), split over o.workers goroutines
+// no new calls are started after a call fails or o.ctx is done, and the error
+// of the failed call with the lowest i is returned, making the result
+// independent of scheduling
+func parallelFor(n int, o options, f func(i int) error) error {
+    var mu sync.Mutex
+    done := 0
+    call := func(i int) error {
+        err := o.ctx.Err()
+        if err != nil {return err}
+        err = f(i)
+        if err != nil {return err}
+        if o.progress != nil {
+            mu.Lock()
+            done += 1
+            o.progress(done, n)
+            mu.Unlock()
+        }
+        return nil
+    }
+    workers := o.workers
+    if workers > n {
+        workers = n
+    }
+    if workers <= 1 {
+        for i := 0; i < n; i += 1 {
+            err := call(i)
+            if err != nil {return err}
+        }
+        return nil
+    }
+    var next int64 = -1
+    var failed int32
+    var err_mu sync.Mutex
+    var first_err error
+    first_i := n
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w += 1 {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for atomic.LoadInt32(&failed) == 0 {
+                i := int(atomic.AddInt64(&next, 1))
+                if i >= n {
+                    return
+                }
+                err := call(i)
+                if err != nil {
+                    err_mu.Lock()
+                    if i < first_i {
+                        first_i, first_err = i, err
+                    }
+                    err_mu.Unlock()
+                    atomic.StoreInt32(&failed, 1)
+                }
+            }
+        }()
+    }
+    wg.Wait()
+    return first_err
+}
@@ -0,0 +1,157 @@
+package genmatrix
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math/big"
+    "sync/atomic"
+    "testing"
+)
+
+func TestParallelFor(t *testing.T) {
+    t.Run("all indices", func(t *testing.T) {
+        seen := make([]int32, 100)
+        err := parallelFor(len(seen), newOptions([]Option{WithWorkers(8)}), func(i int) error {
+            atomic.AddInt32(&seen[i], 1)
+            return nil
+        })
+        if err != nil {t.Fatal(err)}
+        for i, n := range seen {
+            if n != 1 {t.Errorf("index %d visited %d times", i, n)}
+        }
+    })
+    t.Run("first error", func(t *testing.T) {
+        var calls int32
+        err := parallelFor(10000, newOptions([]Option{WithWorkers(4)}), func(i int) error {
+            atomic.AddInt32(&calls, 1)
+            if i >= 10 {
+                return fmt.Errorf("failed at %d", i)
+            }
+            return nil
+        })
+        if err == nil {t.Fatal("no error returned")}
+        if calls == 10000 {t.Error("work was not cancelled after error")}
+    })
+    t.Run("sequential", func(t *testing.T) {
+        fail := errors.New("fail")
+        var calls int
+        err := parallelFor(10, newOptions([]Option{WithWorkers(1)}), func(i int) error {
+            calls += 1
+            if i == 3 {return fail}
+            return nil
+        })
+        if err != fail {t.Errorf("expected %v, got %v", fail, err)}
+        if calls != 4 {t.Errorf("expected 4 calls, got %d", calls)}
+    })
+}
+
+func TestParallelDeterminism(t *testing.T) {
+    data := make([]int, 20*20)
+    for i := range data {
+        data[i] = i*7 % 13 - 6
+    }
+    a, err := NewMatrixFromInt(20, 20, data)
+    if err != nil {t.Fatal(err)}
+    sequential, err := a.Multiply(a, WithWorkers(1))
+    if err != nil {t.Fatal(err)}
+    for _, workers := range []int{0, 2, 16} {
+        parallel, err := a.Multiply(a, WithWorkers(workers))
+        if err != nil {t.Fatal(err)}
+        Compare(parallel, sequential, t)
+        sum, err := a.Add(a, WithWorkers(workers))
+        if err != nil {t.Fatal(err)}
+        scaled, err := a.Scale(big.NewInt(2), WithWorkers(workers))
+        if err != nil {t.Fatal(err)}
+        Compare(sum, scaled, t)
+    }
+    t.Run("error", func(t *testing.T) {
+        b, err := NewMatrix(20, 20, nil, Bigint{})
+        if err != nil {t.Fatal(err)}
+        _, err = a.Add(b, WithWorkers(4))
+        if err == nil {t.Error("no error on uninitialized elements")}
+    })
+}
+
+func TestApplySequential(t *testing.T) {
+    a, err := NewMatrixFromInt(10, 10, make([]int, 100))
+    if err != nil {t.Fatal(err)}
+    // not safe for concurrent use, which Apply allows without WithWorkers
+    var seen []interface{}
+    _, err = a.Apply(func(v interface{}) (interface{}, error) {
+        seen = append(seen, v)
+        return v, nil
+    })
+    if err != nil {t.Fatal(err)}
+    if len(seen) != 100 {
+        t.Errorf("expected 100 calls, got %d", len(seen))
+    }
+}
+
+func TestContext(t *testing.T) {
+    a, err := NewMatrixFromInt(10, 10, make([]int, 100))
+    if err != nil {t.Fatal(err)}
+    t.Run("cancelled before start", func(t *testing.T) {
+        ctx, cancel := context.WithCancel(context.Background())
+        cancel()
+        _, err := a.MultiplyContext(ctx, a)
+        if !errors.Is(err, context.Canceled) {t.Errorf("expected context.Canceled, got %v", err)}
+        _, err = a.ScaleContext(ctx, big.NewInt(2))
+        if !errors.Is(err, context.Canceled) {t.Errorf("expected context.Canceled, got %v", err)}
+    })
+    t.Run("cancelled while running", func(t *testing.T) {
+        ctx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+        var calls int32
+        _, err := a.ApplyContext(ctx, func(v interface{}) (interface{}, error) {
+            if atomic.AddInt32(&calls, 1) == 10 {
+                cancel()
+            }
+            return v, nil
+        }, WithWorkers(2))
+        if !errors.Is(err, context.Canceled) {t.Errorf("expected context.Canceled, got %v", err)}
+        if calls == 100 {t.Error("work was not cancelled")}
+    })
+    t.Run("encryption", func(t *testing.T) {
+        cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        ctx, cancel := context.WithCancel(context.Background())
+        ae, err := EncryptMatrixContext(ctx, a, cs)
+        if err != nil {t.Fatal(err)}
+        cancel()
+        _, err = DecryptMatrixContext(ctx, ae, djsks)
+        if !errors.Is(err, context.Canceled) {t.Errorf("expected context.Canceled, got %v", err)}
+        _, err = EncryptMatrixContext(ctx, a, cs)
+        if !errors.Is(err, context.Canceled) {t.Errorf("expected context.Canceled, got %v", err)}
+    })
+}
+
+func TestProgress(t *testing.T) {
+    a, err := NewMatrixFromInt(3, 4, make([]int, 12))
+    if err != nil {t.Fatal(err)}
+    t.Run("apply", func(t *testing.T) {
+        last, calls := 0, 0
+        _, err := a.Apply(func(v interface{}) (interface{}, error) {return v, nil}, WithWorkers(3), WithProgress(func(done, total int) {
+            calls += 1
+            if total != 12 {t.Errorf("expected total 12, got %d", total)}
+            if done != last + 1 {t.Errorf("progress went from %d to %d", last, done)}
+            last = done
+        }))
+        if err != nil {t.Fatal(err)}
+        if calls != 12 || last != 12 {t.Errorf("expected 12 progress calls ending at 12, got %d ending at %d", calls, last)}
+    })
+    t.Run("decryption", func(t *testing.T) {
+        cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+        if err != nil {t.Fatal(err)}
+        ae, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        last := 0
+        _, err = DecryptMatrix(ae, djsks, WithProgress(func(done, total int) {
+            if total != 4*12 {t.Errorf("expected total %d, got %d", 4*12, total)}
+            if done <= last {t.Errorf("progress went from %d to %d", last, done)}
+            last = done
+        }))
+        if err != nil {t.Fatal(err)}
+        if last != 4*12 {t.Errorf("progress ended at %d", last)}
+    })
+}
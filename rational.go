@@ -0,0 +1,249 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+)
+
+var (
+    // a rational element has a denominator other than 1
+    ErrNotInteger = errors.New("element is not an integer")
+    // no fraction with small enough numerator and denominator matches the residue
+    ErrNoReconstruction = errors.New("no rational reconstruction")
+)
+
+// Rational is the field of rational numbers, with elements *big.Rat
+type Rational struct {}
+
+// assert that an element is a non-nil *big.Rat
+func toRat(v interface{}) (*big.Rat, error) {
+    r, ok := v.(*big.Rat)
+    if !ok || r == nil {
+        return nil, fmt.Errorf("%w: expected *big.Rat, but %T", ErrElementType, v)
+    }
+    return r, nil
+}
+
+func assertRat(a, b interface{}) (x, y *big.Rat, err error) {
+    x, err = toRat(a)
+    if err != nil {return}
+    y, err = toRat(b)
+    return
+}
+
+func (q Rational) Add(a, b interface{}) (interface{}, error) {
+    x, y, err := assertRat(a, b)
+    if err != nil {return nil, err}
+    return new(big.Rat).Add(x, y), nil
+}
+
+func (q Rational) Subtract(a, b interface{}) (interface{}, error) {
+    x, y, err := assertRat(a, b)
+    if err != nil {return nil, err}
+    return new(big.Rat).Sub(x, y), nil
+}
+
+func (q Rational) Multiply(a, b interface{}) (interface{}, error) {
+    x, y, err := assertRat(a, b)
+    if err != nil {return nil, err}
+    return new(big.Rat).Mul(x, y), nil
+}
+
+// scale by a *big.Rat or *big.Int factor
+func (q Rational) Scale(a, b interface{}) (interface{}, error) {
+    if i, ok := b.(*big.Int); ok && i != nil {
+        b = new(big.Rat).SetInt(i)
+    }
+    return q.Multiply(a, b)
+}
+
+func (q Rational) Scalarspace() bool {
+    return true
+}
+
+func (q Rational) Divide(a, b interface{}) (interface{}, error) {
+    x, y, err := assertRat(a, b)
+    if err != nil {return nil, err}
+    if y.Sign() == 0 {
+        return nil, errors.New("division by zero")
+    }
+    return new(big.Rat).Quo(x, y), nil
+}
+
+func (q Rational) Inverse(a interface{}) (interface{}, error) {
+    x, err := toRat(a)
+    if err != nil {return nil, err}
+    if x.Sign() == 0 {
+        return nil, fmt.Errorf("%w: 0", ErrNotInvertible)
+    }
+    return new(big.Rat).Inv(x), nil
+}
+
+func (q Rational) IsZero(a interface{}) bool {
+    x, err := toRat(a)
+    return err == nil && x.Sign() == 0
+}
+
+func (q Rational) Zero() interface{} {
+    return new(big.Rat)
+}
+
+func (q Rational) One() interface{} {
+    return big.NewRat(1, 1)
+}
+
+func (q Rational) Negate(a interface{}) (interface{}, error) {
+    x, err := toRat(a)
+    if err != nil {return nil, err}
+    return new(big.Rat).Neg(x), nil
+}
+
+func (q Rational) Equal(a, b interface{}) (bool, error) {
+    x, y, err := assertRat(a, b)
+    if err != nil {return false, err}
+    return x.Cmp(y) == 0, nil
+}
+
+// create a new Matrix of rationals from the fractions nums[i] / dens[i]
+func NewRationalMatrix(rows, cols int, nums, dens []int64) (Matrix, error) {
+    if len(nums) != len(dens) {
+        return Matrix{}, fmt.Errorf("%d numerators for %d denominators", len(nums), len(dens))
+    }
+    vals := make([]interface{}, len(nums))
+    for i := range nums {
+        if dens[i] == 0 {
+            return Matrix{}, fmt.Errorf("denominator %d is zero", i)
+        }
+        vals[i] = big.NewRat(nums[i], dens[i])
+    }
+    return NewMatrix(rows, cols, vals, Rational{})
+}
+
+// convert the Bigint matrix a to a matrix of rationals
+func ToRational(a Matrix) (Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        b, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        vals[i] = new(big.Rat).SetInt(b)
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, Rational{})
+}
+
+// convert the matrix of rationals a to a Bigint matrix, if every denominator is 1
+func FromRational(a Matrix) (Matrix, error) {
+    if _, ok := a.Space.(Rational); !ok {
+        return Matrix{}, fmt.Errorf("%w: space %T is not Rational", ErrNotSupported, a.Space)
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        x, err := toRat(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        if !x.IsInt() {
+            return Matrix{}, elementError(a, i, fmt.Errorf("%w: %v", ErrNotInteger, x))
+        }
+        vals[i] = new(big.Int).Set(x.Num())
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, Bigint{})
+}
+
+// write the matrix of rationals a as b / den, with b a Bigint matrix and den
+// the least common denominator of the elements of a
+func ClearDenominators(a Matrix) (b Matrix, den *big.Int, err error) {
+    if _, ok := a.Space.(Rational); !ok {
+        return Matrix{}, nil, fmt.Errorf("%w: space %T is not Rational", ErrNotSupported, a.Space)
+    }
+    den = big.NewInt(1)
+    gcd := new(big.Int)
+    for i, v := range a.values {
+        x, err := toRat(v)
+        if err != nil {return Matrix{}, nil, elementError(a, i, err)}
+        // lcm(den, d) = den * d / gcd(den, d)
+        gcd.GCD(nil, nil, den, x.Denom())
+        den.Mul(den, new(big.Int).Quo(x.Denom(), gcd))
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        x := v.(*big.Rat)
+        num := new(big.Int).Quo(den, x.Denom())
+        vals[i] = num.Mul(num, x.Num())
+    }
+    b, err = NewMatrix(a.Rows, a.Cols, vals, Bigint{})
+    return
+}
+
+// find the fraction n / d congruent to x modulo m with |n|, d <= sqrt(m / 2),
+// which is unique if it exists
+// used to recover rational results of a computation done modulo a large m
+func RationalReconstruction(x, m *big.Int) (*big.Rat, error) {
+    if m == nil || x == nil || m.Sign() <= 0 {
+        return nil, errors.New("modulus must be positive")
+    }
+    bound := new(big.Int).Sqrt(new(big.Int).Rsh(m, 1))
+    // extended Euclid on (m, x), stopped at the first remainder within the bound,
+    // keeping r = t * x mod m
+    r0, r1 := new(big.Int).Set(m), new(big.Int).Mod(x, m)
+    t0, t1 := new(big.Int), big.NewInt(1)
+    for r1.Cmp(bound) > 0 {
+        q := new(big.Int).Quo(r0, r1)
+        r0, r1 = r1, new(big.Int).Sub(r0, new(big.Int).Mul(q, r1))
+        t0, t1 = t1, new(big.Int).Sub(t0, new(big.Int).Mul(q, t1))
+    }
+    if t1.CmpAbs(bound) > 0 || new(big.Int).GCD(nil, nil, r1, new(big.Int).Abs(t1)).Cmp(big.NewInt(1)) != 0 {
+        return nil, fmt.Errorf("%w: %v modulo %v", ErrNoReconstruction, x, m)
+    }
+    return new(big.Rat).SetFrac(r1, t1), nil
+}
+
+// reconstruct the rational matrix whose reduction is the matrix a in Zn, see RationalReconstruction
+func ReconstructRational(a Matrix) (Matrix, error) {
+    z, ok := a.Space.(Zn)
+    if !ok {
+        return Matrix{}, fmt.Errorf("%w: space %T is not Zn", ErrNotSupported, a.Space)
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        b, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        vals[i], err = RationalReconstruction(b, z.N)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, Rational{})
+}
+
+func (q Rational) SpaceName() string {return "rational"}
+func (q Rational) MarshalSpace() ([]byte, error) {return nil, nil}
+
+// numerator and denominator, nil is encoded as no bytes
+func (q Rational) MarshalElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    x, err := toRat(a)
+    if err != nil {return nil, err}
+    var e encoder
+    e.bigint(x.Num())
+    e.bigint(x.Denom())
+    return e.buf, nil
+}
+
+func (q Rational) UnmarshalElement(b []byte) (interface{}, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    d := decoder{buf: b}
+    num, den := d.bigint(), d.bigint()
+    if d.err != nil {return nil, d.err}
+    if num == nil || den == nil || den.Sign() == 0 || len(d.buf) != 0 {
+        return nil, errors.New("malformed rational element")
+    }
+    return new(big.Rat).SetFrac(num, den), nil
+}
+
+func init() {
+    RegisterSpace("rational", func([]byte) (SerializableSpace, error) {return Rational{}, nil})
+}
@@ -0,0 +1,110 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func compareRationals(a, b Matrix, t *testing.T) {
+    if a.Rows != b.Rows || a.Cols != b.Cols {
+        t.Fatalf("differing dimensions (%d x %d and %d x %d)", a.Rows, a.Cols, b.Rows, b.Cols)
+    }
+    for i := range a.values {
+        if a.values[i].(*big.Rat).Cmp(b.values[i].(*big.Rat)) != 0 {
+            t.Errorf("values differ at %d: %v != %v", i, a.values[i], b.values[i])
+        }
+    }
+}
+
+func TestRational(t *testing.T) {
+    a, err := NewRationalMatrix(2, 2, []int64{1, -2, 3, 5}, []int64{2, 3, 1, 4})
+    if err != nil {t.Fatal(err)}
+    t.Run("arithmetic", func(t *testing.T) {
+        c, err := a.Add(a)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewRationalMatrix(2, 2, []int64{1, -4, 6, 5}, []int64{1, 3, 1, 2})
+        if err != nil {t.Fatal(err)}
+        compareRationals(c, correct, t)
+        c, err = a.Multiply(a)
+        if err != nil {t.Fatal(err)}
+        correct, err = NewRationalMatrix(2, 2, []int64{-7, -7, 21, -7}, []int64{4, 6, 4, 16})
+        if err != nil {t.Fatal(err)}
+        compareRationals(c, correct, t)
+        c, err = a.Scale(big.NewInt(6))
+        if err != nil {t.Fatal(err)}
+        correct, err = NewRationalMatrix(2, 2, []int64{3, -4, 18, 15}, []int64{1, 1, 1, 2})
+        if err != nil {t.Fatal(err)}
+        compareRationals(c, correct, t)
+    })
+    t.Run("exact inverse of integer matrix", func(t *testing.T) {
+        b, err := NewMatrixFromInt(3, 3, []int{2, -1, 0, -1, 2, -1, 0, -1, 2})
+        if err != nil {t.Fatal(err)}
+        br, err := ToRational(b)
+        if err != nil {t.Fatal(err)}
+        inv, err := br.Inverse()
+        if err != nil {t.Fatal(err)}
+        correct, err := NewRationalMatrix(3, 3, []int64{3, 1, 1, 1, 1, 1, 1, 1, 3}, []int64{4, 2, 4, 2, 1, 2, 4, 2, 4})
+        if err != nil {t.Fatal(err)}
+        compareRationals(inv, correct, t)
+        scaled, den, err := ClearDenominators(inv)
+        if err != nil {t.Fatal(err)}
+        if den.Int64() != 4 {
+            t.Errorf("expected common denominator 4, got %v", den)
+        }
+        correct_scaled, err := NewMatrixFromInt(3, 3, []int{3, 2, 1, 2, 4, 2, 1, 2, 3})
+        if err != nil {t.Fatal(err)}
+        Compare(scaled, correct_scaled, t)
+        id, err := inv.Multiply(br)
+        if err != nil {t.Fatal(err)}
+        id, err = FromRational(id)
+        if err != nil {t.Fatal(err)}
+        correct_id, err := Identity(3, Bigint{})
+        if err != nil {t.Fatal(err)}
+        Compare(id, correct_id, t)
+        _, err = FromRational(inv)
+        if !errors.Is(err, ErrNotInteger) {t.Errorf("expected ErrNotInteger, got %v", err)}
+        det, err := br.Determinant()
+        if err != nil {t.Fatal(err)}
+        if det.(*big.Rat).Cmp(big.NewRat(4, 1)) != 0 {
+            t.Errorf("expected determinant 4, got %v", det)
+        }
+    })
+    t.Run("serialization", func(t *testing.T) {
+        enc, err := a.MarshalJSON()
+        if err != nil {t.Fatal(err)}
+        var b Matrix
+        err = b.UnmarshalJSON(enc)
+        if err != nil {t.Fatal(err)}
+        compareRationals(b, a, t)
+    })
+}
+
+func TestRationalReconstruction(t *testing.T) {
+    p := big.NewInt(1000003)
+    for _, r := range []*big.Rat{big.NewRat(3, 7), big.NewRat(-22, 35), big.NewRat(0, 1), big.NewRat(700, 1)} {
+        x := new(big.Int).ModInverse(r.Denom(), p)
+        x.Mul(x, r.Num()).Mod(x, p)
+        rec, err := RationalReconstruction(x, p)
+        if err != nil {t.Fatal(err)}
+        if rec.Cmp(r) != 0 {
+            t.Errorf("expected %v, got %v", r, rec)
+        }
+    }
+    // modulo 11 only 0, ±1, ±2 and ±1/2 have small enough numerator and denominator
+    _, err := RationalReconstruction(big.NewInt(3), big.NewInt(11))
+    if !errors.Is(err, ErrNoReconstruction) {t.Errorf("expected ErrNoReconstruction, got %v", err)}
+    t.Run("matrix", func(t *testing.T) {
+        z, err := NewZn(p)
+        if err != nil {t.Fatal(err)}
+        b, err := z.NewMatrixFromInt(2, 2, []int{4, 1, 2, 3})
+        if err != nil {t.Fatal(err)}
+        inv, err := b.Inverse()
+        if err != nil {t.Fatal(err)}
+        rec, err := ReconstructRational(inv)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewRationalMatrix(2, 2, []int64{3, -1, -1, 2}, []int64{10, 10, 5, 5})
+        if err != nil {t.Fatal(err)}
+        compareRationals(rec, correct, t)
+    })
+}
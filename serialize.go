@@ -0,0 +1,378 @@
+package genmatrix
+
+import (
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "sync"
+    "github.com/niclabs/tcpaillier"
+)
+
+// SerializableSpace is a Space whose matrices can be marshalled
+// the receiving side recreates the space from its parameters with the
+// function registered for its name with RegisterSpace
+type SerializableSpace interface {
+    Space
+
+    // name the space is registered under
+    SpaceName() string
+
+    // parameters needed to recreate the space
+    MarshalSpace() ([]byte, error)
+
+    // encoding of a single element
+    MarshalElement(interface{}) ([]byte, error)
+
+    // decoding of a single element
+    UnmarshalElement([]byte) (interface{}, error)
+}
+
+// no space is registered under the name of a marshalled matrix
+var ErrUnknownSpace = errors.New("unknown space")
+
+var (
+    registry_mu sync.RWMutex
+    registry = make(map[string]func(params []byte) (SerializableSpace, error))
+)
+
+// make the space with the given name available to unmarshalling, where
+// f recreates the space from the parameters given by MarshalSpace
+// panics if name is already registered
+func RegisterSpace(name string, f func(params []byte) (SerializableSpace, error)) {
+    registry_mu.Lock()
+    defer registry_mu.Unlock()
+    if _, dup := registry[name]; dup {
+        panic("genmatrix: space registered twice: " + name)
+    }
+    registry[name] = f
+}
+
+func init() {
+    RegisterSpace("bigint", func([]byte) (SerializableSpace, error) {return Bigint{}, nil})
+    RegisterSpace("zn", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        n := d.bigint()
+        if d.err != nil {return nil, d.err}
+        return NewZn(n)
+    })
+    RegisterSpace("dj", func(params []byte) (SerializableSpace, error) {
+        pk, err := unmarshalPubKey(params)
+        if err != nil {return nil, err}
+        return DJ_public_key{pk}, nil
+    })
+}
+
+// portable form of a matrix, shared by the JSON and binary encodings
+type matrixData struct {
+    Space string `json:"space"`
+    Params []byte `json:"params,omitempty"`
+    Rows int `json:"rows"`
+    Cols int `json:"cols"`
+    Values [][]byte `json:"values"`
+}
+
+func (m Matrix) toData() (data matrixData, err error) {
+    s, ok := m.Space.(SerializableSpace)
+    if !ok {
+        err = fmt.Errorf("%w: %T is not serializable", ErrNotSupported, m.Space)
+        return
+    }
+    data.Space, data.Rows, data.Cols = s.SpaceName(), m.Rows, m.Cols
+    data.Params, err = s.MarshalSpace()
+    if err != nil {return}
+    data.Values = make([][]byte, len(m.values))
+    for i, v := range m.values {
+        data.Values[i], err = s.MarshalElement(v)
+        if err != nil {return data, elementError(m, i, err)}
+    }
+    return
+}
+
+func (m *Matrix) fromData(data matrixData) error {
+    registry_mu.RLock()
+    f, ok := registry[data.Space]
+    registry_mu.RUnlock()
+    if !ok {
+        return fmt.Errorf("%w: %q", ErrUnknownSpace, data.Space)
+    }
+    if !sizeMatches(data.Rows, data.Cols, len(data.Values)) {
+        return fmt.Errorf("%d values for a %d x %d matrix", len(data.Values), data.Rows, data.Cols)
+    }
+    space, err := f(data.Params)
+    if err != nil {return err}
+    vals := make([]interface{}, len(data.Values))
+    for i, b := range data.Values {
+        vals[i], err = space.UnmarshalElement(b)
+        if err != nil {return ElementError{Row: i / data.Cols, Col: i % data.Cols, Err: err}}
+    }
+    res, err := NewMatrix(data.Rows, data.Cols, vals, space)
+    if err != nil {return err}
+    *m = res
+    return nil
+}
+
+// true if a rows x cols matrix has n elements, without computing rows * cols,
+// which may overflow for untrusted sizes
+func sizeMatches(rows, cols, n int) bool {
+    if rows < 0 || cols < 0 {
+        return false
+    }
+    if rows == 0 || cols == 0 {
+        return n == 0
+    }
+    return rows <= n / cols && rows * cols == n
+}
+
+// encode m, including its space, as JSON
+// the space of m must be a SerializableSpace
+func (m Matrix) MarshalJSON() ([]byte, error) {
+    data, err := m.toData()
+    if err != nil {return nil, err}
+    return json.Marshal(data)
+}
+
+// decode a matrix encoded by MarshalJSON
+func (m *Matrix) UnmarshalJSON(b []byte) error {
+    var data matrixData
+    err := json.Unmarshal(b, &data)
+    if err != nil {return err}
+    return m.fromData(data)
+}
+
+// version of the binary matrix encoding
+const matrixEncodingVersion = 1
+
+const maxInt = uint64(^uint(0) >> 1)
+
+// encode m, including its space, in a compact binary form, also used by encoding/gob
+// the space of m must be a SerializableSpace
+func (m Matrix) MarshalBinary() ([]byte, error) {
+    data, err := m.toData()
+    if err != nil {return nil, err}
+    var e encoder
+    e.uvarint(matrixEncodingVersion)
+    e.bytes([]byte(data.Space))
+    e.bytes(data.Params)
+    e.uvarint(uint64(data.Rows))
+    e.uvarint(uint64(data.Cols))
+    for _, v := range data.Values {
+        e.bytes(v)
+    }
+    return e.buf, nil
+}
+
+// decode a matrix encoded by MarshalBinary
+func (m *Matrix) UnmarshalBinary(b []byte) error {
+    d := decoder{buf: b}
+    if v := d.uvarint(); d.err == nil && v != matrixEncodingVersion {
+        return fmt.Errorf("unsupported matrix encoding version %d", v)
+    }
+    var data matrixData
+    data.Space = string(d.bytes())
+    data.Params = d.bytes()
+    rows, cols := d.uvarint(), d.uvarint()
+    if d.err != nil {return d.err}
+    // every value takes at least one byte, checked without overflowing rows * cols
+    if rows > maxInt || cols > maxInt || (cols != 0 && rows > uint64(len(d.buf)) / cols) {
+        return fmt.Errorf("%d x %d matrix exceeds the encoded data", rows, cols)
+    }
+    data.Rows, data.Cols = int(rows), int(cols)
+    data.Values = make([][]byte, data.Rows*data.Cols)
+    for i := range data.Values {
+        data.Values[i] = d.bytes()
+    }
+    if d.err != nil {return d.err}
+    if len(d.buf) != 0 {
+        return fmt.Errorf("%d trailing bytes after matrix", len(d.buf))
+    }
+    return m.fromData(data)
+}
+
+// appends length-prefixed fields to buf
+type encoder struct {
+    buf []byte
+}
+
+func (e *encoder) uvarint(v uint64) {
+    var b [binary.MaxVarintLen64]byte
+    e.buf = append(e.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (e *encoder) bytes(b []byte) {
+    e.uvarint(uint64(len(b)))
+    e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) bigint(b *big.Int) {
+    e.bytes(marshalBigint(b))
+}
+
+// reads fields written by encoder, keeping the first error
+type decoder struct {
+    buf []byte
+    err error
+}
+
+var errTruncated = errors.New("truncated data")
+
+func (d *decoder) uvarint() uint64 {
+    if d.err != nil {
+        return 0
+    }
+    v, n := binary.Uvarint(d.buf)
+    if n <= 0 {
+        d.err = errTruncated
+        return 0
+    }
+    d.buf = d.buf[n:]
+    return v
+}
+
+func (d *decoder) bytes() []byte {
+    l := d.uvarint()
+    if d.err != nil {
+        return nil
+    }
+    if l > uint64(len(d.buf)) {
+        d.err = errTruncated
+        return nil
+    }
+    b := d.buf[:l:l]
+    d.buf = d.buf[l:]
+    return b
+}
+
+func (d *decoder) bigint() *big.Int {
+    b := d.bytes()
+    if d.err != nil {
+        return nil
+    }
+    v, err := unmarshalBigint(b)
+    if err != nil {
+        d.err = err
+        return nil
+    }
+    return v
+}
+
+// a sign byte followed by the absolute value, nil is encoded as no bytes
+func marshalBigint(b *big.Int) []byte {
+    if b == nil {
+        return nil
+    }
+    sign := byte(0)
+    if b.Sign() < 0 {
+        sign = 1
+    }
+    return append([]byte{sign}, b.Bytes()...)
+}
+
+func unmarshalBigint(b []byte) (*big.Int, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    v := new(big.Int).SetBytes(b[1:])
+    switch b[0] {
+    case 0:
+        return v, nil
+    case 1:
+        return v.Neg(v), nil
+    }
+    return nil, fmt.Errorf("invalid sign byte %d", b[0])
+}
+
+// public key fields needed to recreate the key, Delta and Constant are derived
+func marshalPubKey(pk *tcpaillier.PubKey) []byte {
+    var e encoder
+    e.bigint(pk.N)
+    e.bigint(pk.V)
+    e.buf = append(e.buf, pk.S, pk.L, pk.K)
+    e.uvarint(uint64(len(pk.Vi)))
+    for _, vi := range pk.Vi {
+        e.bigint(vi)
+    }
+    return e.buf
+}
+
+func unmarshalPubKey(b []byte) (*tcpaillier.PubKey, error) {
+    d := decoder{buf: b}
+    pk := &tcpaillier.PubKey{N: d.bigint(), V: d.bigint()}
+    if d.err != nil {return nil, d.err}
+    if len(d.buf) < 3 {
+        return nil, errTruncated
+    }
+    pk.S, pk.L, pk.K = d.buf[0], d.buf[1], d.buf[2]
+    d.buf = d.buf[3:]
+    if n := d.uvarint(); d.err == nil && n != uint64(pk.L) {
+        return nil, fmt.Errorf("public key for %d parties has %d verification keys", pk.L, n)
+    }
+    pk.Vi = make([]*big.Int, pk.L)
+    for i := range pk.Vi {
+        pk.Vi[i] = d.bigint()
+    }
+    if d.err != nil {return nil, d.err}
+    if len(d.buf) != 0 {
+        return nil, fmt.Errorf("%d trailing bytes after public key", len(d.buf))
+    }
+    if pk.N == nil || pk.N.Sign() <= 0 || pk.V == nil || pk.S < 1 || pk.K < 1 || pk.K > pk.L {
+        return nil, errors.New("malformed public key")
+    }
+    for _, vi := range pk.Vi {
+        if vi == nil {
+            return nil, errors.New("malformed public key")
+        }
+    }
+    pk.Delta = new(big.Int).MulRange(1, int64(pk.L))
+    n_to_s := new(big.Int).Exp(pk.N, big.NewInt(int64(pk.S)), nil)
+    pk.Constant = new(big.Int).Mul(pk.Delta, pk.Delta)
+    pk.Constant.Mul(pk.Constant, big.NewInt(4))
+    if pk.Constant.ModInverse(pk.Constant, n_to_s) == nil {
+        return nil, errors.New("malformed public key")
+    }
+    pk.Cache()
+    return pk, nil
+}
+
+// element codec shared by the spaces of *big.Int elements
+func marshalBigintElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    b, err := toBigint(a)
+    if err != nil {return nil, err}
+    return marshalBigint(b), nil
+}
+
+func unmarshalBigintElement(b []byte) (interface{}, error) {
+    v, err := unmarshalBigint(b)
+    if v == nil || err != nil {
+        return nil, err
+    }
+    return v, nil
+}
+
+func (p Bigint) SpaceName() string {return "bigint"}
+func (p Bigint) MarshalSpace() ([]byte, error) {return nil, nil}
+func (p Bigint) MarshalElement(a interface{}) ([]byte, error) {return marshalBigintElement(a)}
+func (p Bigint) UnmarshalElement(b []byte) (interface{}, error) {return unmarshalBigintElement(b)}
+
+func (z Zn) SpaceName() string {return "zn"}
+func (z Zn) MarshalSpace() ([]byte, error) {
+    var e encoder
+    e.bigint(z.N)
+    return e.buf, nil
+}
+func (z Zn) MarshalElement(a interface{}) ([]byte, error) {return marshalBigintElement(a)}
+func (z Zn) UnmarshalElement(b []byte) (interface{}, error) {return unmarshalBigintElement(b)}
+
+func (pk DJ_public_key) SpaceName() string {return "dj"}
+func (pk DJ_public_key) MarshalSpace() ([]byte, error) {
+    if pk.PubKey == nil {
+        return nil, errors.New("public key can't be nil")
+    }
+    return marshalPubKey(pk.PubKey), nil
+}
+func (pk DJ_public_key) MarshalElement(a interface{}) ([]byte, error) {return marshalBigintElement(a)}
+func (pk DJ_public_key) UnmarshalElement(b []byte) (interface{}, error) {return unmarshalBigintElement(b)}
@@ -0,0 +1,145 @@
+package genmatrix
+
+import (
+    "bytes"
+    "encoding/gob"
+    "encoding/json"
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestSerialization(t *testing.T) {
+    a, err := NewMatrixFromInt(2, 3, []int{3, -4, 0, 1, 800, -5})
+    if err != nil {t.Fatal(err)}
+    codecs := map[string]struct{
+        marshal func(Matrix) ([]byte, error)
+        unmarshal func([]byte, *Matrix) error
+    }{
+        "json": {func(m Matrix) ([]byte, error) {return json.Marshal(m)}, func(b []byte, m *Matrix) error {return json.Unmarshal(b, m)}},
+        "binary": {Matrix.MarshalBinary, func(b []byte, m *Matrix) error {return m.UnmarshalBinary(b)}},
+        "gob": {
+            func(m Matrix) ([]byte, error) {
+                var buf bytes.Buffer
+                err := gob.NewEncoder(&buf).Encode(m)
+                return buf.Bytes(), err
+            },
+            func(b []byte, m *Matrix) error {return gob.NewDecoder(bytes.NewReader(b)).Decode(m)},
+        },
+    }
+    for name, codec := range codecs {
+        t.Run(name, func(t *testing.T) {
+            t.Run("bigint", func(t *testing.T) {
+                b, err := codec.marshal(a)
+                if err != nil {t.Fatal(err)}
+                var c Matrix
+                err = codec.unmarshal(b, &c)
+                if err != nil {t.Fatal(err)}
+                if _, ok := c.Space.(Bigint); !ok {
+                    t.Errorf("unmarshalled matrix in space %T", c.Space)
+                }
+                Compare(c, a, t)
+            })
+            t.Run("zn", func(t *testing.T) {
+                z, err := NewZn(big.NewInt(7))
+                if err != nil {t.Fatal(err)}
+                az, err := z.Reduce(a)
+                if err != nil {t.Fatal(err)}
+                b, err := codec.marshal(az)
+                if err != nil {t.Fatal(err)}
+                var c Matrix
+                err = codec.unmarshal(b, &c)
+                if err != nil {t.Fatal(err)}
+                if cz, ok := c.Space.(Zn); !ok || cz.N.Cmp(z.N) != 0 {
+                    t.Errorf("unmarshalled matrix in space %v", c.Space)
+                }
+                Compare(c, az, t)
+            })
+            t.Run("encrypted", func(t *testing.T) {
+                cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+                if err != nil {t.Fatal(err)}
+                plain, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+                if err != nil {t.Fatal(err)}
+                ae, err := EncryptMatrix(plain, cs)
+                if err != nil {t.Fatal(err)}
+                b, err := codec.marshal(ae)
+                if err != nil {t.Fatal(err)}
+                var c Matrix
+                err = codec.unmarshal(b, &c)
+                if err != nil {t.Fatal(err)}
+                pk, ok := c.Space.(DJ_public_key)
+                if !ok {t.Fatalf("unmarshalled matrix in space %T", c.Space)}
+                if !samePubKey(pk.PubKey, cs.PubKey) || pk.Delta.Cmp(cs.Delta) != 0 || pk.Constant.Cmp(cs.Constant) != 0 {
+                    t.Error("public key changed in serialization")
+                }
+                // the reconstructed key must still work for further computation
+                c, err = c.Add(ae)
+                if err != nil {t.Fatal(err)}
+                c, err = DecryptMatrix(c, djsks)
+                if err != nil {t.Fatal(err)}
+                double, err := NewMatrixFromInt(2, 2, []int{2, 4, 6, 8})
+                if err != nil {t.Fatal(err)}
+                Compare(c, double, t)
+            })
+        })
+    }
+    t.Run("uninitialized element", func(t *testing.T) {
+        b, err := NewMatrix(1, 2, nil, Bigint{})
+        if err != nil {t.Fatal(err)}
+        err = b.Set(0, 1, big.NewInt(3))
+        if err != nil {t.Fatal(err)}
+        enc, err := b.MarshalBinary()
+        if err != nil {t.Fatal(err)}
+        var c Matrix
+        err = c.UnmarshalBinary(enc)
+        if err != nil {t.Fatal(err)}
+        if v, _ := c.At(0, 0); v != nil {
+            t.Errorf("expected nil element, got %v", v)
+        }
+        if v, _ := decode(c.At(0, 1)); v.Cmp(big.NewInt(3)) != 0 {
+            t.Errorf("expected 3, got %v", v)
+        }
+    })
+    t.Run("unknown space", func(t *testing.T) {
+        var c Matrix
+        err := json.Unmarshal([]byte(`{"space":"nope","rows":0,"cols":0,"values":[]}`), &c)
+        if !errors.Is(err, ErrUnknownSpace) {t.Errorf("expected ErrUnknownSpace, got %v", err)}
+    })
+    t.Run("not serializable", func(t *testing.T) {
+        b, err := NewMatrix(1, 1, []interface{}{big.NewInt(1)}, struct{Space}{Bigint{}})
+        if err != nil {t.Fatal(err)}
+        _, err = b.MarshalBinary()
+        if !errors.Is(err, ErrNotSupported) {t.Errorf("expected ErrNotSupported, got %v", err)}
+    })
+    t.Run("corrupt", func(t *testing.T) {
+        enc, err := a.MarshalBinary()
+        if err != nil {t.Fatal(err)}
+        var c Matrix
+        for _, b := range [][]byte{enc[:len(enc)-1], append(enc, 0), {2}} {
+            if c.UnmarshalBinary(b) == nil {
+                t.Errorf("accepted corrupt encoding %v", b)
+            }
+        }
+        err = json.Unmarshal([]byte(`{"space":"bigint","rows":2,"cols":2,"values":[]}`), &c)
+        if err == nil {t.Error("accepted values not matching dimensions")}
+    })
+    t.Run("overflowing dimensions", func(t *testing.T) {
+        // 2^32 * 2^32 overflows to 0 values
+        var c Matrix
+        err := json.Unmarshal([]byte(`{"space":"bigint","rows":4294967296,"cols":4294967296,"values":[]}`), &c)
+        if err == nil {t.Error("accepted overflowing dimensions")}
+        for _, dims := range [][2]uint64{{1 << 32, 1 << 32}, {1 << 63, 2}} {
+            var e encoder
+            e.uvarint(matrixEncodingVersion)
+            e.bytes([]byte("bigint"))
+            e.bytes(nil)
+            e.uvarint(dims[0])
+            e.uvarint(dims[1])
+            if c.UnmarshalBinary(e.buf) == nil {t.Errorf("accepted %d x %d matrix in binary", dims[0], dims[1])}
+        }
+        // empty matrices have no values to bound their size
+        err = json.Unmarshal([]byte(`{"space":"bigint","rows":3,"cols":0,"values":[]}`), &c)
+        if err != nil {t.Fatal(err)}
+        if c.Rows != 3 || c.Cols != 0 {t.Errorf("expected 3 x 0 matrix, got %d x %d", c.Rows, c.Cols)}
+    })
+}
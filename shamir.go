@@ -0,0 +1,378 @@
+package genmatrix
+
+import (
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "math/big"
+)
+
+// fewer shares than needed for the degree of the sharing polynomial
+var ErrTooFewShares = errors.New("too few shares to reconstruct")
+
+// Share is one party's Shamir share of a secret: the value of a random
+// polynomial over Fp with the secret as constant term, at the party's point
+type Share struct {
+    Value FpElement
+    // degree of the sharing polynomial, one less than the number of shares
+    // needed to reconstruct
+    Degree int
+}
+
+// Shamir is the space of the shares held by one party, with elements Share
+// addition and scaling by public factors are local, while multiplication
+// gives shares of twice the degree, see MultiplyShared
+type Shamir struct {
+    Field Fp
+    // the point at which the party evaluates the sharing polynomials,
+    // its transport index + 1
+    Point uint64
+}
+
+// assert that an element is a Share
+func toShare(v interface{}) (Share, error) {
+    a, ok := v.(Share)
+    if !ok {
+        return Share{}, fmt.Errorf("%w: expected Share, but %T", ErrElementType, v)
+    }
+    return a, nil
+}
+
+func assertShares(a, b interface{}) (x, y Share, err error) {
+    x, err = toShare(a)
+    if err != nil {return}
+    y, err = toShare(b)
+    return
+}
+
+func maxDegree(a, b int) int {
+    if a > b {
+        return a
+    }
+    return b
+}
+
+func (s Shamir) Add(a, b interface{}) (interface{}, error) {
+    x, y, err := assertShares(a, b)
+    if err != nil {return nil, err}
+    sum, err := s.Field.Add(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Share{sum.(FpElement), maxDegree(x.Degree, y.Degree)}, nil
+}
+
+func (s Shamir) Subtract(a, b interface{}) (interface{}, error) {
+    x, y, err := assertShares(a, b)
+    if err != nil {return nil, err}
+    diff, err := s.Field.Subtract(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Share{diff.(FpElement), maxDegree(x.Degree, y.Degree)}, nil
+}
+
+// local product of shares, a share of the product of the secrets for
+// a polynomial of the sum of the degrees
+func (s Shamir) Multiply(a, b interface{}) (interface{}, error) {
+    x, y, err := assertShares(a, b)
+    if err != nil {return nil, err}
+    prod, err := s.Field.Multiply(x.Value, y.Value)
+    if err != nil {return nil, err}
+    return Share{prod.(FpElement), x.Degree + y.Degree}, nil
+}
+
+// scale by a public FpElement or *big.Int factor
+func (s Shamir) Scale(a, b interface{}) (interface{}, error) {
+    x, err := toShare(a)
+    if err != nil {return nil, err}
+    prod, err := s.Field.Scale(x.Value, b)
+    if err != nil {return nil, err}
+    return Share{prod.(FpElement), x.Degree}, nil
+}
+
+func (s Shamir) Scalarspace() bool {
+    return false
+}
+
+// a share of 0 by the constant polynomial
+func (s Shamir) Zero() interface{} {
+    return Share{}
+}
+
+func (s Shamir) Negate(a interface{}) (interface{}, error) {
+    x, err := toShare(a)
+    if err != nil {return nil, err}
+    neg, err := s.Field.Negate(x.Value)
+    if err != nil {return nil, err}
+    return Share{neg.(FpElement), x.Degree}, nil
+}
+
+// uniformly random element of f
+func randomFp(f Fp) (FpElement, error) {
+    x, err := rand.Int(rand.Reader, new(big.Int).SetUint64(f.p))
+    if err != nil {return 0, err}
+    return f.Element(x.Uint64()), nil
+}
+
+// share each of the secrets with a random polynomial of the given degree
+// among n parties, shares[i][k] being the share of secrets[k] of party i
+func shareValues(f Fp, secrets []FpElement, n, degree int) ([][]interface{}, error) {
+    shares := make([][]interface{}, n)
+    for i := range shares {
+        shares[i] = make([]interface{}, len(secrets))
+    }
+    coeffs := make([]uint64, degree+1)
+    for k, secret := range secrets {
+        coeffs[0] = uint64(secret)
+        for d := 1; d <= degree; d += 1 {
+            r, err := randomFp(f)
+            if err != nil {return nil, err}
+            coeffs[d] = uint64(r)
+        }
+        // evaluate at the points 1, ..., n by Horner's rule
+        for i := range shares {
+            x := uint64(f.Element(uint64(i + 1)))
+            y := coeffs[degree]
+            for d := degree - 1; d >= 0; d -= 1 {
+                y = f.mul(y, x) + coeffs[d]
+                if y >= f.p {
+                    y -= f.p
+                }
+            }
+            shares[i][k] = Share{FpElement(y), degree}
+        }
+    }
+    return shares, nil
+}
+
+// Lagrange coefficients for interpolating the value at 0 of a polynomial
+// from its values at points
+func lagrangeAtZero(f Fp, points []uint64) ([]FpElement, error) {
+    coeffs := make([]FpElement, len(points))
+    for i, x_i := range points {
+        num, den := uint64(f.Element(1)), uint64(f.Element(1))
+        for j, x_j := range points {
+            if j == i {
+                continue
+            }
+            // x_j / (x_j - x_i)
+            num = f.mul(num, uint64(f.Element(x_j)))
+            den = f.mul(den, f.sub(uint64(f.Element(x_j)), uint64(f.Element(x_i))))
+        }
+        inv, err := f.Inverse(FpElement(den))
+        if err != nil {return nil, fmt.Errorf("points are not distinct: %w", err)}
+        coeffs[i] = FpElement(f.mul(num, uint64(inv.(FpElement))))
+    }
+    return coeffs, nil
+}
+
+// split the Bigint matrix a into Shamir shares over f for n parties, any t of
+// which reconstruct a, returning the share matrix of each party
+// party i evaluates at point i + 1, and elements of a must lie within
+// (-p/2, p/2) to be recovered, otherwise ErrPlaintextOverflow is returned
+func ShareMatrix(a Matrix, f Fp, n, t int) ([]Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return nil, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    if t < 1 || t > n || uint64(n) >= f.p {
+        return nil, fmt.Errorf("invalid threshold %d of %d parties modulo %d", t, n, f.p)
+    }
+    p := new(big.Int).SetUint64(f.p)
+    max := new(big.Int).SetUint64(f.p / 2)
+    secrets := make([]FpElement, len(a.values))
+    for i, v := range a.values {
+        x, err := toBigint(v)
+        if err != nil {return nil, elementError(a, i, err)}
+        if x.CmpAbs(max) > 0 {
+            return nil, elementError(a, i, fmt.Errorf("%w: |%v| exceeds %v", ErrPlaintextOverflow, x, max))
+        }
+        secrets[i] = f.Element(new(big.Int).Mod(x, p).Uint64())
+    }
+    vals, err := shareValues(f, secrets, n, t - 1)
+    if err != nil {return nil, err}
+    shares := make([]Matrix, n)
+    for i := range shares {
+        shares[i], err = NewMatrix(a.Rows, a.Cols, vals[i], Shamir{f, uint64(i + 1)})
+        if err != nil {return nil, err}
+    }
+    return shares, nil
+}
+
+// the degree of the shares of a, asserting that a is a matrix of shares
+func sharedDegree(a Matrix) (Shamir, int, error) {
+    s, ok := a.Space.(Shamir)
+    if !ok {
+        return Shamir{}, 0, fmt.Errorf("%w: space %T is not Shamir", ErrNotSupported, a.Space)
+    }
+    degree := 0
+    for i, v := range a.values {
+        x, err := toShare(v)
+        if err != nil {return Shamir{}, 0, elementError(a, i, err)}
+        degree = maxDegree(degree, x.Degree)
+    }
+    return s, degree, nil
+}
+
+// reconstruct the Bigint matrix from share matrices of distinct parties,
+// e.g. any t of those from ShareMatrix
+// the elements are given in (-p/2, p/2)
+func ReconstructShared(shares []Matrix) (Matrix, error) {
+    if len(shares) == 0 {
+        return Matrix{}, fmt.Errorf("%w: no shares", ErrTooFewShares)
+    }
+    degree := 0
+    points := make([]uint64, len(shares))
+    for i, m := range shares {
+        s, d, err := sharedDegree(m)
+        if err != nil {return Matrix{}, err}
+        if s.Field.p != shares[0].Space.(Shamir).Field.p {
+            return Matrix{}, fmt.Errorf("shares %d and 0 are in different fields", i)
+        }
+        if m.Rows != shares[0].Rows || m.Cols != shares[0].Cols {
+            return Matrix{}, fmt.Errorf("shares %d and 0 differ in size", i)
+        }
+        degree = maxDegree(degree, d)
+        points[i] = s.Point
+    }
+    if len(shares) <= degree {
+        return Matrix{}, fmt.Errorf("%w: %d shares of degree %d", ErrTooFewShares, len(shares), degree)
+    }
+    shares, points = shares[:degree+1], points[:degree+1]
+    f := shares[0].Space.(Shamir).Field
+    coeffs, err := lagrangeAtZero(f, points)
+    if err != nil {return Matrix{}, err}
+    vals := make([]interface{}, len(shares[0].values))
+    for k := range vals {
+        var y uint64
+        for i, m := range shares {
+            y += f.mul(uint64(m.values[k].(Share).Value), uint64(coeffs[i]))
+            if y >= f.p {
+                y -= f.p
+            }
+        }
+        x := new(big.Int).SetUint64(f.Uint64(FpElement(y)))
+        if x.Uint64() > f.p / 2 {
+            x.Sub(x, new(big.Int).SetUint64(f.p))
+        }
+        vals[k] = x
+    }
+    return NewMatrix(shares[0].Rows, shares[0].Cols, vals, Bigint{})
+}
+
+// multiply the share matrices a and b together with the other parties
+// every party calls MultiplyShared with its shares of a and b and its
+// transport, and gets its share of a * b of the larger degree of a and b
+//
+// the local product of the shares is of twice the degree, which is reduced
+// in one round by resharing it and interpolating the reshares, requiring
+// more parties than the degree of the product
+// the protocol protects against semi-honest parties only, and a party that
+// fails aborts the protocol for all parties, see Transport.Abort
+func MultiplyShared(a, b Matrix, tr Transport, opts ...Option) (product Matrix, err error) {
+    defer abortOnError(tr, &err)
+    s, a_deg, err := sharedDegree(a)
+    if err != nil {return Matrix{}, err}
+    b_s, b_deg, err := sharedDegree(b)
+    if err != nil {return Matrix{}, err}
+    if s != b_s {
+        return Matrix{}, errors.New("shares of a and b belong to different parties or fields")
+    }
+    if s.Point != uint64(tr.Index() + 1) {
+        return Matrix{}, fmt.Errorf("shares at point %d used by party %d", s.Point, tr.Index())
+    }
+    n := tr.Parties()
+    if a_deg + b_deg >= n {
+        return Matrix{}, fmt.Errorf("%w: product of degree %d among %d parties", ErrTooFewShares, a_deg + b_deg, n)
+    }
+    c, err := a.Multiply(b, opts...)
+    if err != nil {return Matrix{}, err}
+    degree := maxDegree(a_deg, b_deg)
+
+    // reshare the local product with the target degree
+    secrets := make([]FpElement, len(c.values))
+    for i, v := range c.values {
+        secrets[i] = v.(Share).Value
+    }
+    vals, err := shareValues(s.Field, secrets, n, degree)
+    if err != nil {return Matrix{}, err}
+    out := make([]interface{}, n)
+    for j := range out {
+        out[j], err = NewMatrix(c.Rows, c.Cols, vals[j], Shamir{s.Field, uint64(j + 1)})
+        if err != nil {return Matrix{}, err}
+    }
+    msgs, err := tr.Exchange(out)
+    if err != nil {return Matrix{}, err}
+
+    // interpolate the reshares of all parties at 0
+    points := make([]uint64, n)
+    for j := range points {
+        points[j] = uint64(j + 1)
+    }
+    coeffs, err := lagrangeAtZero(s.Field, points)
+    if err != nil {return Matrix{}, err}
+    var sum Matrix
+    for j, msg := range msgs {
+        m, ok := msg.(Matrix)
+        if !ok {
+            return Matrix{}, fmt.Errorf("party %d sent %T, expected Matrix", j, msg)
+        }
+        if m.Space != s || m.Rows != c.Rows || m.Cols != c.Cols {
+            return Matrix{}, fmt.Errorf("party %d sent a %d x %d matrix in %v, expected %d x %d in %v", j, m.Rows, m.Cols, m.Space, c.Rows, c.Cols, s)
+        }
+        m, err = m.Scale(coeffs[j], opts...)
+        if err != nil {return Matrix{}, fmt.Errorf("party %d: %w", j, err)}
+        if j == 0 {
+            sum = m
+        } else {
+            sum, err = sum.Add(m, opts...)
+            if err != nil {return Matrix{}, fmt.Errorf("party %d: %w", j, err)}
+        }
+    }
+    return sum, nil
+}
+
+func (s Shamir) SpaceName() string {return "shamir"}
+
+func (s Shamir) MarshalSpace() ([]byte, error) {
+    var e encoder
+    e.uvarint(s.Field.p)
+    e.uvarint(s.Point)
+    return e.buf, nil
+}
+
+// the value in [0, p) and the degree
+func (s Shamir) MarshalElement(a interface{}) ([]byte, error) {
+    if a == nil {
+        return nil, nil
+    }
+    x, err := toShare(a)
+    if err != nil {return nil, err}
+    var e encoder
+    e.uvarint(s.Field.Uint64(x.Value))
+    e.uvarint(uint64(x.Degree))
+    return e.buf, nil
+}
+
+func (s Shamir) UnmarshalElement(b []byte) (interface{}, error) {
+    if len(b) == 0 {
+        return nil, nil
+    }
+    d := decoder{buf: b}
+    x, degree := d.uvarint(), d.uvarint()
+    if d.err != nil {return nil, d.err}
+    if x >= s.Field.p || degree >= s.Field.p || len(d.buf) != 0 {
+        return nil, errors.New("malformed Shamir share")
+    }
+    return Share{s.Field.Element(x), int(degree)}, nil
+}
+
+func init() {
+    RegisterSpace("shamir", func(params []byte) (SerializableSpace, error) {
+        d := decoder{buf: params}
+        p, point := d.uvarint(), d.uvarint()
+        if d.err != nil {return nil, d.err}
+        f, err := NewFp(p)
+        if err != nil {return nil, err}
+        if point == 0 || point >= p {
+            return nil, errors.New("malformed Shamir parameters")
+        }
+        return Shamir{f, point}, nil
+    })
+}
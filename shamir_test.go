@@ -0,0 +1,143 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestShamir(t *testing.T) {
+    f, err := NewFp(NTTPrime62)
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 3, []int{1, -2, 3, 4, 5, -6})
+    if err != nil {t.Fatal(err)}
+    shares, err := ShareMatrix(a, f, 5, 3)
+    if err != nil {t.Fatal(err)}
+    t.Run("reconstruct", func(t *testing.T) {
+        for _, parties := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 2, 3, 4}, {0, 1, 2, 3, 4}} {
+            subset := make([]Matrix, len(parties))
+            for i, j := range parties {
+                subset[i] = shares[j]
+            }
+            b, err := ReconstructShared(subset)
+            if err != nil {t.Fatal(err)}
+            Compare(b, a, t)
+        }
+        _, err := ReconstructShared(shares[3:])
+        if !errors.Is(err, ErrTooFewShares) {t.Errorf("expected ErrTooFewShares, got %v", err)}
+    })
+    t.Run("local operations", func(t *testing.T) {
+        correct, err := a.Add(a)
+        if err != nil {t.Fatal(err)}
+        correct, err = correct.Scale(big.NewInt(-3))
+        if err != nil {t.Fatal(err)}
+        results := make([]Matrix, len(shares))
+        for i, s := range shares {
+            results[i], err = s.Add(s)
+            if err != nil {t.Fatal(err)}
+            results[i], err = results[i].Scale(big.NewInt(-3))
+            if err != nil {t.Fatal(err)}
+        }
+        b, err := ReconstructShared(results[2:])
+        if err != nil {t.Fatal(err)}
+        Compare(b, correct, t)
+    })
+    t.Run("public matrix", func(t *testing.T) {
+        p, err := NewMatrixFromInt(2, 2, []int{2, 0, -1, 1})
+        if err != nil {t.Fatal(err)}
+        correct, err := p.Multiply(a)
+        if err != nil {t.Fatal(err)}
+        results := make([]Matrix, len(shares))
+        for i, s := range shares {
+            results[i], err = p.Multiply(s)
+            if err != nil {t.Fatal(err)}
+        }
+        b, err := ReconstructShared(results[:3])
+        if err != nil {t.Fatal(err)}
+        Compare(b, correct, t)
+    })
+    t.Run("overflow", func(t *testing.T) {
+        small, err := NewFp(101)
+        if err != nil {t.Fatal(err)}
+        b, err := NewMatrixFromInt(1, 2, []int{50, -51})
+        if err != nil {t.Fatal(err)}
+        _, err = ShareMatrix(b, small, 3, 2)
+        if !errors.Is(err, ErrPlaintextOverflow) {t.Errorf("expected ErrPlaintextOverflow, got %v", err)}
+    })
+    t.Run("serialization", func(t *testing.T) {
+        enc, err := shares[1].MarshalBinary()
+        if err != nil {t.Fatal(err)}
+        var b Matrix
+        err = b.UnmarshalBinary(enc)
+        if err != nil {t.Fatal(err)}
+        c, err := ReconstructShared([]Matrix{shares[0], b, shares[2]})
+        if err != nil {t.Fatal(err)}
+        Compare(c, a, t)
+    })
+}
+
+func TestMultiplyShared(t *testing.T) {
+    f, err := NewFp(NTTPrime62)
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 3, []int{1, -2, 3, 4, 5, -6})
+    if err != nil {t.Fatal(err)}
+    b, err := NewMatrixFromInt(3, 2, []int{7, 8, -9, 10, 11, 12})
+    if err != nil {t.Fatal(err)}
+    correct, err := a.Multiply(b)
+    if err != nil {t.Fatal(err)}
+    correct, err = correct.Multiply(b.Transpose())
+    if err != nil {t.Fatal(err)}
+    a_shares, err := ShareMatrix(a, f, 5, 3)
+    if err != nil {t.Fatal(err)}
+    b_shares, err := ShareMatrix(b, f, 5, 3)
+    if err != nil {t.Fatal(err)}
+    bt_shares, err := ShareMatrix(b.Transpose(), f, 5, 3)
+    if err != nil {t.Fatal(err)}
+    t.Run("vanilla", func(t *testing.T) {
+        results, errs := runParties(5, func(tr Transport) (Matrix, error) {
+            i := tr.Index()
+            c, err := MultiplyShared(a_shares[i], b_shares[i], tr)
+            if err != nil {return Matrix{}, err}
+            // the degree is reduced, so products can be chained
+            return MultiplyShared(c, bt_shares[i], tr)
+        })
+        for i, err := range errs {
+            if err != nil {t.Fatalf("party %d: %v", i, err)}
+        }
+        for i := range results {
+            _, degree, err := sharedDegree(results[i])
+            if err != nil {t.Fatal(err)}
+            if degree != 2 {
+                t.Errorf("party %d: expected degree 2, got %d", i, degree)
+            }
+        }
+        c, err := ReconstructShared(results[1:4])
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+    t.Run("party fails", func(t *testing.T) {
+        errs := runPartiesFailing(t, 5, 0, func(tr Transport) (Matrix, error) {
+            return MultiplyShared(a_shares[tr.Index()], b_shares[tr.Index()], tr)
+        })
+        for i, err := range errs[1:] {
+            if !errors.Is(err, ErrAborted) {t.Errorf("party %d: expected ErrAborted, got %v", i + 1, err)}
+        }
+    })
+    t.Run("too few parties", func(t *testing.T) {
+        _, errs := runParties(4, func(tr Transport) (Matrix, error) {
+            return MultiplyShared(a_shares[tr.Index()], b_shares[tr.Index()], tr)
+        })
+        for i, err := range errs {
+            if !errors.Is(err, ErrTooFewShares) {t.Errorf("party %d: expected ErrTooFewShares, got %v", i, err)}
+        }
+    })
+    t.Run("wrong party", func(t *testing.T) {
+        _, errs := runParties(5, func(tr Transport) (Matrix, error) {
+            i := tr.Index()
+            return MultiplyShared(a_shares[i], b_shares[(i + 1) % 5], tr)
+        })
+        for i, err := range errs {
+            if err == nil {t.Errorf("party %d: multiplied shares of different parties", i)}
+        }
+    })
+}
@@ -18,3 +18,62 @@ type Space interface {
     // i.e. if Scale are to be used in matrix multiplication
     Scalarspace() bool
 }
+
+// optional capabilities of a Space, used by matrix operations that need them
+
+// Zeroer is implemented by spaces with an additive identity
+type Zeroer interface {
+
+    // the additive identity, a new value on every call
+    Zero() interface{}
+}
+
+// Oner is implemented by spaces with a multiplicative identity
+type Oner interface {
+
+    // the multiplicative identity, a new value on every call
+    One() interface{}
+}
+
+// Negator is implemented by spaces with additive inverses
+type Negator interface {
+
+    // the additive inverse of an element
+    Negate(interface{}) (negation interface{}, err error)
+}
+
+// Equaler is implemented by spaces where elements can be compared
+type Equaler interface {
+
+    // return true if a and b are the same element of the space
+    Equal(a, b interface{}) (bool, error)
+}
+
+// Inverter is implemented by spaces with multiplicative inverses
+type Inverter interface {
+
+    // multiplicative inverse of an element, fails if there is none
+    Inverse(interface{}) (inverse interface{}, err error)
+}
+
+// Field is a Space in which division by non-zero elements is possible,
+// as needed for Gaussian elimination
+type Field interface {
+    Space
+    Inverter
+
+    // return true if the element is the additive identity
+    IsZero(interface{}) bool
+}
+
+// ExactDivider is a Space in which an element can be divided by any of its
+// divisors, as needed for fraction-free elimination
+type ExactDivider interface {
+    Space
+
+    // a / b, fails if b does not divide a
+    Divide(a, b interface{}) (quotient interface{}, err error)
+
+    // return true if the element is the additive identity
+    IsZero(interface{}) bool
+}
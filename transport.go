@@ -0,0 +1,124 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+)
+
+// another party aborted the protocol
+var ErrAborted = errors.New("protocol aborted")
+
+// Transport connects the parties of an interactive protocol
+// every round of a protocol is one call to Exchange by each party
+type Transport interface {
+    // number of parties, including this one
+    Parties() int
+    // index of this party, from 0 to Parties()-1
+    Index() int
+    // send out[j] to party j and return in, where in[j] is the message received from party j
+    // in[Index()] is out[Index()]
+    Exchange(out []interface{}) (in []interface{}, err error)
+    // abort the protocol because of err, so that pending and later calls to
+    // Exchange of all parties fail with ErrAborted instead of waiting
+    Abort(err error)
+}
+
+// abort the protocol on tr if *err is set, to be deferred by protocols so that
+// no party is left waiting for a party that failed
+func abortOnError(tr Transport, err *error) {
+    if *err != nil {
+        tr.Abort(*err)
+    }
+}
+
+// send the same message to all parties and return the messages from all parties
+func broadcast(tr Transport, msg interface{}) ([]interface{}, error) {
+    out := make([]interface{}, tr.Parties())
+    for i := range out {
+        out[i] = msg
+    }
+    return tr.Exchange(out)
+}
+
+// transport between parties in the same process
+type localTransport struct {
+    index int
+    *localNetwork
+}
+
+type localNetwork struct {
+    // inboxes[to][from] carries the messages from party from to party to
+    inboxes [][]chan interface{}
+    // closed on the first abort, which sets err
+    aborted chan struct{}
+    abort_once sync.Once
+    err error
+}
+
+// create connected transports for n parties in the same process, e.g. for tests
+// each transport is to be used by a separate goroutine
+func NewLocalTransports(n int) []Transport {
+    inboxes := make([][]chan interface{}, n)
+    for i := range inboxes {
+        inboxes[i] = make([]chan interface{}, n)
+        for j := range inboxes[i] {
+            inboxes[i][j] = make(chan interface{}, 1)
+        }
+    }
+    network := &localNetwork{inboxes: inboxes, aborted: make(chan struct{})}
+    trs := make([]Transport, n)
+    for i := range trs {
+        trs[i] = localTransport{index: i, localNetwork: network}
+    }
+    return trs
+}
+
+func (tr localTransport) Parties() int {
+    return len(tr.inboxes)
+}
+
+func (tr localTransport) Index() int {
+    return tr.index
+}
+
+func (tr localTransport) Exchange(out []interface{}) ([]interface{}, error) {
+    if len(out) != len(tr.inboxes) {
+        return nil, fmt.Errorf("expected %d messages, got %d", len(tr.inboxes), len(out))
+    }
+    select {
+    case <-tr.aborted:
+        return nil, tr.err
+    default:
+    }
+    for to, msg := range out {
+        if to != tr.index {
+            select {
+            case tr.inboxes[to][tr.index] <- msg:
+            case <-tr.aborted:
+                return nil, tr.err
+            }
+        }
+    }
+    in := make([]interface{}, len(out))
+    for from, inbox := range tr.inboxes[tr.index] {
+        if from == tr.index {
+            in[from] = out[from]
+        } else {
+            select {
+            case in[from] = <-inbox:
+            case <-tr.aborted:
+                return nil, tr.err
+            }
+        }
+    }
+    return in, nil
+}
+
+// only the first abort takes effect
+func (tr localTransport) Abort(err error) {
+    tr.abort_once.Do(func() {
+        tr.err = fmt.Errorf("%w by party %d: %v", ErrAborted, tr.index, err)
+        close(tr.aborted)
+    })
+}
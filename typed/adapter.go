@@ -0,0 +1,86 @@
+package typed
+
+import (
+    "fmt"
+    "github.com/ontanj/generic-matrix"
+)
+
+// convert an interface{}-based matrix to a typed matrix in space
+// fails if an element of m is not of type T
+func FromMatrix[T any](m genmatrix.Matrix, space Space[T]) (Matrix[T], error) {
+    values := make([]T, m.Rows*m.Cols)
+    for i := 0; i < m.Rows; i += 1 {
+        for j := 0; j < m.Cols; j += 1 {
+            v, err := m.At(i, j)
+            if err != nil {return Matrix[T]{}, err}
+            t, ok := v.(T)
+            if !ok {
+                return Matrix[T]{}, fmt.Errorf("element (%d, %d) is %T, not %T", i, j, v, t)
+            }
+            values[i*m.Cols+j] = t
+        }
+    }
+    return NewMatrix(m.Rows, m.Cols, values, space)
+}
+
+// convert a to an interface{}-based matrix in space
+func (a Matrix[T]) ToMatrix(space genmatrix.Space) (genmatrix.Matrix, error) {
+    values := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        values[i] = v
+    }
+    return genmatrix.NewMatrix(a.Rows, a.Cols, values, space)
+}
+
+// Untyped adapts a typed Space to genmatrix.Space, so that a typed space can
+// be used for interface{}-based matrices
+type Untyped[T any] struct {
+    Space Space[T]
+    // reported by Scalarspace
+    Scalar bool
+}
+
+func assertType[T any](a, b interface{}) (a_t, b_t T, err error) {
+    a_t, ok := a.(T)
+    if !ok {
+        err = fmt.Errorf("first operator is not %T, but %T", a_t, a)
+        return
+    }
+    b_t, ok = b.(T)
+    if !ok {
+        err = fmt.Errorf("second operator is not %T, but %T", b_t, b)
+    }
+    return
+}
+
+func (u Untyped[T]) Add(a, b interface{}) (interface{}, error) {
+    a_t, b_t, err := assertType[T](a, b)
+    if err != nil {return nil, err}
+    return u.Space.Add(a_t, b_t)
+}
+
+func (u Untyped[T]) Subtract(a, b interface{}) (interface{}, error) {
+    a_t, b_t, err := assertType[T](a, b)
+    if err != nil {return nil, err}
+    return u.Space.Subtract(a_t, b_t)
+}
+
+func (u Untyped[T]) Multiply(a, b interface{}) (interface{}, error) {
+    a_t, b_t, err := assertType[T](a, b)
+    if err != nil {return nil, err}
+    return u.Space.Multiply(a_t, b_t)
+}
+
+// scale using Scale if the typed space is a Scaler[T, T], otherwise Multiply
+func (u Untyped[T]) Scale(a, b interface{}) (interface{}, error) {
+    a_t, b_t, err := assertType[T](a, b)
+    if err != nil {return nil, err}
+    if scaler, ok := u.Space.(Scaler[T, T]); ok {
+        return scaler.Scale(a_t, b_t)
+    }
+    return u.Space.Multiply(a_t, b_t)
+}
+
+func (u Untyped[T]) Scalarspace() bool {
+    return u.Scalar
+}
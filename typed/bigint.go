@@ -0,0 +1,53 @@
+package typed
+
+import (
+    "errors"
+    "math/big"
+)
+
+// Bigint is the typed counterpart of genmatrix.Bigint
+type Bigint struct {}
+
+func assertNotNil(a, b *big.Int) error {
+    if a == nil {
+        return errors.New("first operator is nil")
+    }
+    if b == nil {
+        return errors.New("second operator is nil")
+    }
+    return nil
+}
+
+func (p Bigint) Add(a, b *big.Int) (*big.Int, error) {
+    err := assertNotNil(a, b)
+    if err != nil {return nil, err}
+    return new(big.Int).Add(a, b), nil
+}
+
+func (p Bigint) Subtract(a, b *big.Int) (*big.Int, error) {
+    err := assertNotNil(a, b)
+    if err != nil {return nil, err}
+    return new(big.Int).Sub(a, b), nil
+}
+
+func (p Bigint) Multiply(a, b *big.Int) (*big.Int, error) {
+    err := assertNotNil(a, b)
+    if err != nil {return nil, err}
+    return new(big.Int).Mul(a, b), nil
+}
+
+func (p Bigint) Scale(a, b *big.Int) (*big.Int, error) {
+    return p.Multiply(a, b)
+}
+
+// create a new Matrix from int values
+func NewMatrixFromInt(rows, cols int, data []int) (Matrix[*big.Int], error) {
+    if data == nil {
+        return NewMatrix[*big.Int](rows, cols, nil, Bigint{})
+    }
+    s := make([]*big.Int, len(data))
+    for i, v := range data {
+        s[i] = big.NewInt(int64(v))
+    }
+    return NewMatrix[*big.Int](rows, cols, s, Bigint{})
+}
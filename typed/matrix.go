@@ -0,0 +1,151 @@
+// Package typed provides matrices with compile-time typed elements, as a
+// generic counterpart to the interface{}-based genmatrix.Matrix.
+package typed
+
+import (
+    "fmt"
+)
+
+// Space defines the element-wise operations on elements of type T
+type Space[T any] interface {
+
+    // addition of two elements in the space
+    Add(a, b T) (sum T, err error)
+
+    // subtraction of two elements in the space
+    Subtract(a, b T) (diff T, err error)
+
+    // multiplication of two elements in the space
+    Multiply(a, b T) (product T, err error)
+}
+
+// Scaler scales elements of type T by factors of type F,
+// e.g. encrypted values by plaintext values
+type Scaler[T, F any] interface {
+
+    // scaling of an element by factor
+    Scale(spaced T, factor F) (product T, err error)
+}
+
+type Matrix[T any] struct {
+    values []T
+    Rows, Cols int
+    Space Space[T]
+}
+
+// create a new Matrix with the given size and data acting in space
+func NewMatrix[T any](rows, cols int, data []T, space Space[T]) (m Matrix[T], err error) {
+    if data == nil {
+        data = make([]T, rows*cols)
+    } else if rows * cols != len(data) {
+        err = fmt.Errorf("Data structure not matching matrix size: %d x %d != %d", rows, cols, len(data))
+        return
+    }
+    if space == nil {
+        err = fmt.Errorf("space can't be nil")
+        return
+    }
+    m.values = data
+    m.Rows = rows
+    m.Cols = cols
+    m.Space = space
+    return
+}
+
+// get value at (row, col), where first row/col is 0.
+func (m Matrix[T]) At(row, col int) (v T, err error) {
+    if row >= m.Rows || col >= m.Cols || row < 0 || col < 0 {
+        err = fmt.Errorf("Index out of bounds: (%d, %d)", row, col)
+        return
+    }
+    return m.values[m.Cols*row + col], nil
+}
+
+// set value at (row, col), where first row/col is 0.
+func (m Matrix[T]) Set(row, col int, value T) error {
+    if row >= m.Rows || col >= m.Cols || row < 0 || col < 0 {
+        return fmt.Errorf("Index out of bounds: (%d, %d)", row, col)
+    }
+    m.values[m.Cols*row + col] = value
+    return nil
+}
+
+// multiply a * b, where both are in the space of a
+func (a Matrix[T]) Multiply(b Matrix[T]) (c Matrix[T], err error) {
+    return multiply(a, b, a.Space.Multiply, a.Space)
+}
+
+// multiply a * b, where a holds factors scaling the elements of b
+func ScaleMultiply[F, T any](a Matrix[F], b Matrix[T], scaler Scaler[T, F]) (Matrix[T], error) {
+    return multiply(a, b, func(a_val F, b_val T) (T, error) {return scaler.Scale(b_val, a_val)}, b.Space)
+}
+
+func multiply[A, B, C any](a Matrix[A], b Matrix[B], mulfunc func(A, B) (C, error), space Space[C]) (c Matrix[C], err error) {
+    if a.Cols != b.Rows {
+        err = fmt.Errorf("matrices a and b are not compatible")
+        return
+    }
+    values := make([]C, a.Rows*b.Cols)
+    for i := 0; i < a.Rows; i += 1 {
+        for j := 0; j < b.Cols; j += 1 {
+            var sum, r C
+            for k := 0; k < a.Cols; k += 1 {
+                r, err = mulfunc(a.values[i*a.Cols+k], b.values[k*b.Cols+j])
+                if err != nil {return}
+                if k == 0 {
+                    sum = r
+                } else {
+                    sum, err = space.Add(r, sum)
+                    if err != nil {return}
+                }
+            }
+            values[i*b.Cols+j] = sum
+        }
+    }
+    return NewMatrix(a.Rows, b.Cols, values, space)
+}
+
+// multiplication of a by a scalar in the same space
+func (a Matrix[T]) MultiplyScalar(scalar T) (Matrix[T], error) {
+    return a.Apply(func(v T) (T, error) {return a.Space.Multiply(v, scalar)})
+}
+
+// scale a by factor
+func Scale[T, F any](a Matrix[T], factor F, scaler Scaler[T, F]) (Matrix[T], error) {
+    return a.Apply(func(v T) (T, error) {return scaler.Scale(v, factor)})
+}
+
+// matrix addition
+func (a Matrix[T]) Add(b Matrix[T]) (Matrix[T], error) {
+    if a.Rows != b.Rows || a.Cols != b.Cols {
+        return Matrix[T]{}, fmt.Errorf("dimension mismatch in addition: %d x %d != %d x %d", a.Rows, a.Cols, b.Rows, b.Cols)
+    }
+    return elementwise(a, b, a.Space.Add)
+}
+
+// matrix subtraction
+func (a Matrix[T]) Subtract(b Matrix[T]) (Matrix[T], error) {
+    if a.Rows != b.Rows || a.Cols != b.Cols {
+        return Matrix[T]{}, fmt.Errorf("dimension mismatch in subtraction: %d x %d != %d x %d", a.Rows, a.Cols, b.Rows, b.Cols)
+    }
+    return elementwise(a, b, a.Space.Subtract)
+}
+
+func elementwise[T any](a, b Matrix[T], f func(T, T) (T, error)) (c Matrix[T], err error) {
+    c_vals := make([]T, len(a.values))
+    for i := range c_vals {
+        c_vals[i], err = f(a.values[i], b.values[i])
+        if err != nil {return}
+    }
+    return NewMatrix(a.Rows, a.Cols, c_vals, a.Space)
+}
+
+// apply function f to all matrix elements
+func (a Matrix[T]) Apply(f func(T) (T, error)) (b Matrix[T], err error) {
+    b_vals := make([]T, len(a.values))
+    for i, v := range a.values {
+        b_vals[i], err = f(v)
+        if err != nil {return}
+    }
+    return NewMatrix(a.Rows, a.Cols, b_vals, a.Space)
+}
@@ -0,0 +1,111 @@
+package typed
+
+import (
+    "math/big"
+    "testing"
+    "github.com/ontanj/generic-matrix"
+)
+
+func Compare(a, b Matrix[*big.Int], t *testing.T) {
+    if a.Rows != b.Rows || a.Cols != b.Cols {
+        t.Fatalf("differing dimensions (%d x %d and %d x %d)", a.Rows, a.Cols, b.Rows, b.Cols)
+    }
+    for i := range a.values {
+        if a.values[i].Cmp(b.values[i]) != 0 {
+            t.Errorf("values differ at (%d, %d)", i / a.Cols, i % a.Cols)
+        }
+    }
+}
+
+func TestMultiplication(t *testing.T) {
+    a, err := NewMatrixFromInt(2, 2, []int{1,2,3,4})
+    if err != nil {t.Fatal(err)}
+    b, err := NewMatrixFromInt(2, 3, []int{1,2,3,4,5,6})
+    if err != nil {t.Fatal(err)}
+    t.Run("vanilla", func(t *testing.T) {
+        c, err := a.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        d, err := NewMatrixFromInt(2, 3, []int{9,12,15,19,26,33})
+        if err != nil {t.Fatal(err)}
+        Compare(c, d, t)
+    })
+    t.Run("scaling", func(t *testing.T) {
+        c, err := ScaleMultiply[*big.Int, *big.Int](a, b, Bigint{})
+        if err != nil {t.Fatal(err)}
+        d, err := NewMatrixFromInt(2, 3, []int{9,12,15,19,26,33})
+        if err != nil {t.Fatal(err)}
+        Compare(c, d, t)
+    })
+    t.Run("dimension mismatch", func(t *testing.T) {
+        _, err := b.Multiply(a)
+        if err == nil {t.Error("no error on dimension mismatch")}
+    })
+}
+
+func TestAdditionSubtraction(t *testing.T) {
+    a, err := NewMatrixFromInt(2, 2, []int{5,3,7,9})
+    if err != nil {t.Fatal(err)}
+    b, err := NewMatrixFromInt(2, 2, []int{1,2,3,4})
+    if err != nil {t.Fatal(err)}
+    sum, err := a.Add(b)
+    if err != nil {t.Fatal(err)}
+    correct, err := NewMatrixFromInt(2, 2, []int{6,5,10,13})
+    if err != nil {t.Fatal(err)}
+    Compare(sum, correct, t)
+    diff, err := a.Subtract(b)
+    if err != nil {t.Fatal(err)}
+    correct, err = NewMatrixFromInt(2, 2, []int{4,1,4,5})
+    if err != nil {t.Fatal(err)}
+    Compare(diff, correct, t)
+    scaled, err := Scale[*big.Int, *big.Int](b, big.NewInt(2), Bigint{})
+    if err != nil {t.Fatal(err)}
+    correct, err = NewMatrixFromInt(2, 2, []int{2,4,6,8})
+    if err != nil {t.Fatal(err)}
+    Compare(scaled, correct, t)
+    c, err := NewMatrixFromInt(2, 3, nil)
+    if err != nil {t.Fatal(err)}
+    _, err = a.Add(c)
+    if err == nil {t.Error("no error on dimension mismatch")}
+    nils, err := b.Apply(func(*big.Int) (*big.Int, error) {return nil, nil})
+    if err != nil {t.Fatal(err)}
+    _, err = a.Add(nils)
+    if err == nil {t.Error("no error on nil element")}
+}
+
+func TestAdapters(t *testing.T) {
+    m, err := genmatrix.NewMatrixFromInt(2, 3, []int{1,2,3,4,5,6})
+    if err != nil {t.Fatal(err)}
+    a, err := FromMatrix[*big.Int](m, Bigint{})
+    if err != nil {t.Fatal(err)}
+    correct, err := NewMatrixFromInt(2, 3, []int{1,2,3,4,5,6})
+    if err != nil {t.Fatal(err)}
+    Compare(a, correct, t)
+    t.Run("back to untyped", func(t *testing.T) {
+        u, err := a.ToMatrix(genmatrix.Bigint{})
+        if err != nil {t.Fatal(err)}
+        v, err := u.At(1, 2)
+        if err != nil {t.Fatal(err)}
+        if v.(*big.Int).Int64() != 6 {
+            t.Errorf("expected 6, got %v", v)
+        }
+    })
+    t.Run("untyped space", func(t *testing.T) {
+        u, err := a.ToMatrix(Untyped[*big.Int]{Space: Bigint{}, Scalar: true})
+        if err != nil {t.Fatal(err)}
+        b, err := genmatrix.NewMatrixFromInt(3, 1, []int{1,1,1})
+        if err != nil {t.Fatal(err)}
+        c, err := u.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        v, err := c.At(1, 0)
+        if err != nil {t.Fatal(err)}
+        if v.(*big.Int).Int64() != 15 {
+            t.Errorf("expected 15, got %v", v)
+        }
+    })
+    t.Run("wrong element type", func(t *testing.T) {
+        n, err := genmatrix.NewMatrix(1, 1, []interface{}{3}, genmatrix.Bigint{})
+        if err != nil {t.Fatal(err)}
+        _, err = FromMatrix[*big.Int](n, Bigint{})
+        if err == nil {t.Error("no error on wrong element type")}
+    })
+}
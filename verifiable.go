@@ -0,0 +1,227 @@
+package genmatrix
+
+import (
+    "crypto/sha256"
+    "errors"
+    "fmt"
+    "math/big"
+    "github.com/niclabs/tcpaillier"
+)
+
+// a zero-knowledge proof did not verify
+var ErrInvalidProof = errors.New("invalid proof")
+
+// EncryptionProof holds one proof of correct encryption per element, in row-major order
+type EncryptionProof []*tcpaillier.EncryptZK
+
+// ScalingProof holds one proof of correct scaling per element, in row-major order
+type ScalingProof []*tcpaillier.MulZK
+
+// encrypt the plaintext Bigint matrix a with pk and prove that each element is a valid encryption
+func EncryptMatrixWithProof(a Matrix, pk DJ_public_key, opts ...Option) (Matrix, EncryptionProof, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return Matrix{}, nil, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    if pk.PubKey == nil {
+        return Matrix{}, nil, errors.New("public key can't be nil")
+    }
+    n_to_s := pk.Cache().NToS
+    b_vals := make([]interface{}, len(a.values))
+    proof := make(EncryptionProof, len(a.values))
+    err := parallelFor(len(b_vals), newOptions(opts), func(i int) error {
+        plain, err := toBigint(a.values[i])
+        if err != nil {return elementError(a, i, err)}
+        plain, err = encodeSigned(plain, n_to_s)
+        if err != nil {return elementError(a, i, err)}
+        b_vals[i], proof[i], err = pk.EncryptWithProof(plain)
+        if err != nil {return elementError(a, i, err)}
+        return nil
+    })
+    if err != nil {return Matrix{}, nil, err}
+    b, err := NewMatrix(a.Rows, a.Cols, b_vals, pk)
+    return b, proof, err
+}
+
+// check that every element of cipher is proven to be a valid encryption
+func VerifyEncryption(cipher Matrix, proof EncryptionProof) error {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return err}
+    if len(proof) != len(cipher.values) {
+        return fmt.Errorf("%w: %d proofs for %d elements", ErrInvalidProof, len(proof), len(cipher.values))
+    }
+    for i, v := range cipher.values {
+        c, err := toBigint(v)
+        if err != nil {return elementError(cipher, i, err)}
+        if proof[i] == nil {
+            return elementError(cipher, i, fmt.Errorf("%w: missing proof", ErrInvalidProof))
+        }
+        err = proof[i].Verify(pk.PubKey, c)
+        if err != nil {return elementError(cipher, i, fmt.Errorf("%w: %v", ErrInvalidProof, err))}
+    }
+    return nil
+}
+
+// scale the encrypted matrix cipher by a public plaintext factor and prove that each element was scaled by it
+// negative factors are encoded as by EncodeSigned
+func ScaleWithProof(cipher Matrix, factor *big.Int, opts ...Option) (Matrix, ScalingProof, error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return Matrix{}, nil, err}
+    alpha, c_alpha, err := scalingFactor(pk, factor)
+    if err != nil {return Matrix{}, nil, err}
+    b_vals := make([]interface{}, len(cipher.values))
+    proof := make(ScalingProof, len(cipher.values))
+    err = parallelFor(len(b_vals), newOptions(opts), func(i int) error {
+        c, err := toBigint(cipher.values[i])
+        if err != nil {return elementError(cipher, i, err)}
+        d, gamma, err := pk.PubKey.Multiply(c, alpha)
+        if err != nil {return elementError(cipher, i, err)}
+        proof[i], err = pk.PubKey.MultiplyProof(c, c_alpha, d, alpha, big.NewInt(1), gamma)
+        if err != nil {return elementError(cipher, i, err)}
+        b_vals[i] = d
+        return nil
+    })
+    if err != nil {return Matrix{}, nil, err}
+    b, err := NewMatrix(cipher.Rows, cipher.Cols, b_vals, pk)
+    return b, proof, err
+}
+
+// encode factor as a plaintext of pk and encrypt it without randomness, so that
+// the verifier can recompute the encrypted factor the scaling proofs refer to
+func scalingFactor(pk DJ_public_key, factor *big.Int) (alpha, c_alpha *big.Int, err error) {
+    if factor == nil {
+        return nil, nil, errors.New("factor can't be nil")
+    }
+    alpha, err = encodeSigned(factor, pk.Cache().NToS)
+    if err != nil {return}
+    c_alpha, err = pk.EncryptFixed(alpha, big.NewInt(1))
+    return
+}
+
+// check that every element of scaled is proven to be the same element in cipher scaled by factor
+func VerifyScaling(cipher, scaled Matrix, factor *big.Int, proof ScalingProof) error {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return err}
+    scaled_pk, err := encryptionKey(scaled)
+    if err != nil {return err}
+    if !samePubKey(pk.PubKey, scaled_pk.PubKey) {
+        return ErrKeyMismatch
+    }
+    if cipher.Rows != scaled.Rows || cipher.Cols != scaled.Cols {
+        return fmt.Errorf("dimension mismatch in scaling: %d x %d != %d x %d", cipher.Rows, cipher.Cols, scaled.Rows, scaled.Cols)
+    }
+    if len(proof) != len(cipher.values) {
+        return fmt.Errorf("%w: %d proofs for %d elements", ErrInvalidProof, len(proof), len(cipher.values))
+    }
+    _, c_alpha, err := scalingFactor(pk, factor)
+    if err != nil {return err}
+    for i := range cipher.values {
+        c, err := toBigint(cipher.values[i])
+        if err != nil {return elementError(cipher, i, err)}
+        d, err := toBigint(scaled.values[i])
+        if err != nil {return elementError(scaled, i, err)}
+        if proof[i] == nil {
+            return elementError(cipher, i, fmt.Errorf("%w: missing proof", ErrInvalidProof))
+        }
+        // each proof refers to its own encrypted factor, which must be the public one
+        if proof[i].CAlpha == nil || proof[i].CAlpha.Cmp(c_alpha) != 0 {
+            return elementError(cipher, i, fmt.Errorf("%w: element is not scaled by %d", ErrInvalidProof, factor))
+        }
+        err = proof[i].Verify(pk.PubKey, d, c)
+        if err != nil {return elementError(cipher, i, fmt.Errorf("%w: %v", ErrInvalidProof, err))}
+    }
+    return nil
+}
+
+// partially decrypt the encrypted matrix cipher with a single key share,
+// attaching a proof of correct decryption for each element
+func PartialDecryptMatrixWithProof(cipher Matrix, sk *tcpaillier.KeyShare, opts ...Option) (part PartialDecryption, err error) {
+    part, err = PartialDecryptMatrix(cipher, sk, opts...)
+    if err != nil {return}
+    part.Proofs = make([]*tcpaillier.DecryptShareZK, len(cipher.values))
+    err = parallelFor(len(part.Proofs), newOptions(opts), func(i int) (err error) {
+        part.Proofs[i], err = partialDecryptProof(sk, cipher.values[i].(*big.Int), part.Shares[i])
+        if err != nil {return elementError(cipher, i, err)}
+        return
+    })
+    return
+}
+
+// prove that ds is the partial decryption of c with sk
+// tcpaillier.KeyShare.PartialDecryptProof draws its randomness from too few
+// bits to hide the secret share, so the proof is computed here instead
+func partialDecryptProof(sk *tcpaillier.KeyShare, c *big.Int, ds *tcpaillier.DecryptionShare) (*tcpaillier.DecryptShareZK, error) {
+    n_to_s_plus_one := sk.Cache().NToSPlusOne
+    // r must statistically hide e * delta * s_i, which is bounded by the bits below
+    r_bits := n_to_s_plus_one.BitLen() + sk.Delta.BitLen() + 2*sha256.Size*8
+    r, err := tcpaillier.RandomInt(r_bits)
+    if err != nil {return nil, err}
+    c_to_4 := new(big.Int).Exp(c, big.NewInt(4), n_to_s_plus_one)
+    a := new(big.Int).Exp(c_to_4, r, n_to_s_plus_one)
+    b := new(big.Int).Exp(sk.V, r, n_to_s_plus_one)
+    ci_to_2 := new(big.Int).Exp(ds.Ci, big.NewInt(2), n_to_s_plus_one)
+    h := sha256.New()
+    h.Write(a.Bytes())
+    h.Write(b.Bytes())
+    h.Write(c_to_4.Bytes())
+    h.Write(ci_to_2.Bytes())
+    e := new(big.Int).SetBytes(h.Sum(nil))
+    z := new(big.Int).Mul(e, sk.Si)
+    z.Mul(z, sk.Delta).Add(z, r)
+    return &tcpaillier.DecryptShareZK{V: sk.V, Vi: sk.Vi[sk.Index-1], E: e, Z: z}, nil
+}
+
+// check that part is a proven partial decryption of cipher
+func VerifyPartialDecryption(cipher Matrix, part PartialDecryption) error {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return err}
+    if part.Rows != cipher.Rows || part.Cols != cipher.Cols {
+        return fmt.Errorf("dimension mismatch in partial decryption: %d x %d != %d x %d", part.Rows, part.Cols, cipher.Rows, cipher.Cols)
+    }
+    digest, err := CiphertextDigest(cipher)
+    if err != nil {return err}
+    if part.Digest != digest {
+        return fmt.Errorf("%w: partial decryption %d is of another ciphertext", ErrInvalidProof, part.Index)
+    }
+    if part.Index < 1 || int(part.Index) > len(pk.Vi) {
+        return fmt.Errorf("%w: no key share with index %d", ErrInvalidProof, part.Index)
+    }
+    if len(part.Shares) != len(cipher.values) || len(part.Proofs) != len(cipher.values) {
+        return fmt.Errorf("%w: partial decryption %d is incomplete", ErrInvalidProof, part.Index)
+    }
+    vi := pk.Vi[part.Index-1]
+    for i, v := range cipher.values {
+        share, proof := part.Shares[i], part.Proofs[i]
+        if share == nil || proof == nil || share.Index != part.Index {
+            return elementError(cipher, i, fmt.Errorf("%w: malformed partial decryption %d", ErrInvalidProof, part.Index))
+        }
+        // the proof carries the verification keys, which must be those of the public key
+        if proof.V == nil || proof.Vi == nil || proof.V.Cmp(pk.V) != 0 || proof.Vi.Cmp(vi) != 0 {
+            return elementError(cipher, i, fmt.Errorf("%w: wrong verification key for partial decryption %d", ErrInvalidProof, part.Index))
+        }
+        err = proof.Verify(pk.PubKey, v.(*big.Int), share)
+        if err != nil {return elementError(cipher, i, fmt.Errorf("%w: %v", ErrInvalidProof, err))}
+    }
+    return nil
+}
+
+// verify the partial decryptions of cipher and combine threshold many valid ones
+// into the plaintext Bigint matrix
+// the indices of partial decryptions failing verification are returned in excluded
+func CombineVerifiedPartialDecryptions(cipher Matrix, parts []PartialDecryption) (plain Matrix, excluded []uint8, err error) {
+    pk, err := encryptionKey(cipher)
+    if err != nil {return}
+    valid := make([]PartialDecryption, 0, len(parts))
+    for _, part := range parts {
+        if VerifyPartialDecryption(cipher, part) != nil {
+            excluded = append(excluded, part.Index)
+        } else {
+            valid = append(valid, part)
+        }
+    }
+    if len(valid) < int(pk.K) {
+        err = fmt.Errorf("needed %d valid partial decryptions, but got %d", pk.K, len(valid))
+        return
+    }
+    plain, err = CombinePartialDecryptions(pk, valid)
+    return
+}
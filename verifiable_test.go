@@ -0,0 +1,126 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestVerifiableEncryption(t *testing.T) {
+    cs, _, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+    if err != nil {t.Fatal(err)}
+    ae, proof, err := EncryptMatrixWithProof(a, cs)
+    if err != nil {t.Fatal(err)}
+    t.Run("valid", func(t *testing.T) {
+        err := VerifyEncryption(ae, proof)
+        if err != nil {t.Error(err)}
+    })
+    t.Run("tampered ciphertext", func(t *testing.T) {
+        other, err := EncryptMatrix(a, cs)
+        if err != nil {t.Fatal(err)}
+        err = VerifyEncryption(other, proof)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+    t.Run("missing proofs", func(t *testing.T) {
+        err := VerifyEncryption(ae, proof[:3])
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+}
+
+func TestVerifiableScaling(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey())
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+    if err != nil {t.Fatal(err)}
+    correct, err := NewMatrixFromInt(2, 2, []int{5, 10, 15, 20})
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptMatrix(a, cs)
+    if err != nil {t.Fatal(err)}
+    scaled, proof, err := ScaleWithProof(ae, big.NewInt(5))
+    if err != nil {t.Fatal(err)}
+    t.Run("valid", func(t *testing.T) {
+        err := VerifyScaling(ae, scaled, big.NewInt(5), proof)
+        if err != nil {t.Error(err)}
+        plain, err := DecryptMatrix(scaled, djsks)
+        if err != nil {t.Fatal(err)}
+        Compare(plain, correct, t)
+    })
+    t.Run("wrong result", func(t *testing.T) {
+        other, err := ae.Scale(big.NewInt(6))
+        if err != nil {t.Fatal(err)}
+        err = VerifyScaling(ae, other, big.NewInt(5), proof)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+    t.Run("wrong factor", func(t *testing.T) {
+        err := VerifyScaling(ae, scaled, big.NewInt(6), proof)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+    t.Run("tampered factor of one element", func(t *testing.T) {
+        other, other_proof, err := ScaleWithProof(ae, big.NewInt(6))
+        if err != nil {t.Fatal(err)}
+        // a valid proof of scaling the first element by another factor
+        vals := append([]interface{}{other.values[0]}, scaled.values[1:]...)
+        tampered, err := NewMatrix(scaled.Rows, scaled.Cols, vals, scaled.Space)
+        if err != nil {t.Fatal(err)}
+        tampered_proof := append(ScalingProof{other_proof[0]}, proof[1:]...)
+        err = VerifyScaling(ae, tampered, big.NewInt(5), tampered_proof)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+    t.Run("negative factor", func(t *testing.T) {
+        negative, proof, err := ScaleWithProof(ae, big.NewInt(-5))
+        if err != nil {t.Fatal(err)}
+        err = VerifyScaling(ae, negative, big.NewInt(-5), proof)
+        if err != nil {t.Error(err)}
+        plain, err := DecryptMatrix(negative, djsks)
+        if err != nil {t.Fatal(err)}
+        correct, err := correct.Negate()
+        if err != nil {t.Fatal(err)}
+        Compare(plain, correct, t)
+    })
+}
+
+func TestVerifiablePartialDecryption(t *testing.T) {
+    cs, djsks, err := NewDJCryptosystem(insecureTestKey(), WithThreshold(2, 3))
+    if err != nil {t.Fatal(err)}
+    a, err := NewMatrixFromInt(1, 3, []int{7, 8, 9})
+    if err != nil {t.Fatal(err)}
+    ae, err := EncryptMatrix(a, cs)
+    if err != nil {t.Fatal(err)}
+    parts := make([]PartialDecryption, len(djsks))
+    for i, sk := range djsks {
+        parts[i], err = PartialDecryptMatrixWithProof(ae, sk)
+        if err != nil {t.Fatal(err)}
+        err = VerifyPartialDecryption(ae, parts[i])
+        if err != nil {t.Errorf("party %d: %v", i+1, err)}
+    }
+    t.Run("without proofs", func(t *testing.T) {
+        part, err := PartialDecryptMatrix(ae, djsks[0])
+        if err != nil {t.Fatal(err)}
+        err = VerifyPartialDecryption(ae, part)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+    })
+    t.Run("exclude malicious party", func(t *testing.T) {
+        malicious := parts[0]
+        malicious.Shares = append(malicious.Shares[:0:0], malicious.Shares...)
+        forged := *malicious.Shares[1]
+        forged.Ci = new(big.Int).Add(forged.Ci, big.NewInt(1))
+        malicious.Shares[1] = &forged
+        err := VerifyPartialDecryption(ae, malicious)
+        if !errors.Is(err, ErrInvalidProof) {t.Errorf("expected ErrInvalidProof, got %v", err)}
+        plain, excluded, err := CombineVerifiedPartialDecryptions(ae, []PartialDecryption{malicious, parts[1], parts[2]})
+        if err != nil {t.Fatal(err)}
+        if len(excluded) != 1 || excluded[0] != malicious.Index {
+            t.Errorf("expected party %d to be excluded, got %v", malicious.Index, excluded)
+        }
+        Compare(plain, a, t)
+    })
+    t.Run("too few valid parties", func(t *testing.T) {
+        unproven, err := PartialDecryptMatrix(ae, djsks[1])
+        if err != nil {t.Fatal(err)}
+        _, excluded, err := CombineVerifiedPartialDecryptions(ae, []PartialDecryption{parts[0], unproven})
+        if err == nil {t.Error("no error on too few valid partial decryptions")}
+        if len(excluded) != 1 {t.Errorf("expected one excluded party, got %v", excluded)}
+    })
+}
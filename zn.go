@@ -0,0 +1,117 @@
+package genmatrix
+
+import (
+    "errors"
+    "fmt"
+    "math/big"
+)
+
+// the element has no multiplicative inverse in the space
+var ErrNotInvertible = errors.New("element is not invertible")
+
+// Zn is the ring of integers modulo N, with elements *big.Int in [0, N)
+// for a prime N it is the field GF(N)
+type Zn struct {
+    N *big.Int
+}
+
+// create the ring of integers modulo n
+func NewZn(n *big.Int) (Zn, error) {
+    if n == nil || n.Cmp(big.NewInt(2)) < 0 {
+        return Zn{}, fmt.Errorf("modulus must be at least 2, but is %v", n)
+    }
+    return Zn{new(big.Int).Set(n)}, nil
+}
+
+func (z Zn) Add(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    sum := new(big.Int).Add(a.(*big.Int), b.(*big.Int))
+    return sum.Mod(sum, z.N), nil
+}
+
+func (z Zn) Subtract(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    diff := new(big.Int).Sub(a.(*big.Int), b.(*big.Int))
+    return diff.Mod(diff, z.N), nil
+}
+
+func (z Zn) Multiply(a, b interface{}) (interface{}, error) {
+    err := assertBigint(a, b)
+    if err != nil {return nil, err}
+    product := new(big.Int).Mul(a.(*big.Int), b.(*big.Int))
+    return product.Mod(product, z.N), nil
+}
+
+// scale by an integer factor, which need not be reduced
+func (z Zn) Scale(a, b interface{}) (interface{}, error) {
+    return z.Multiply(a, b)
+}
+
+func (z Zn) Scalarspace() bool {
+    return true
+}
+
+// multiplicative inverse of a, if gcd(a, N) = 1
+func (z Zn) Inverse(a interface{}) (interface{}, error) {
+    v, err := toBigint(a)
+    if err != nil {return nil, err}
+    inv := new(big.Int).ModInverse(v, z.N)
+    if inv == nil {
+        return nil, fmt.Errorf("%w: %v modulo %v", ErrNotInvertible, v, z.N)
+    }
+    return inv, nil
+}
+
+func (z Zn) Zero() interface{} {
+    return new(big.Int)
+}
+
+func (z Zn) One() interface{} {
+    return new(big.Int).Mod(big.NewInt(1), z.N)
+}
+
+func (z Zn) Negate(a interface{}) (interface{}, error) {
+    v, err := toBigint(a)
+    if err != nil {return nil, err}
+    neg := new(big.Int).Neg(v)
+    return neg.Mod(neg, z.N), nil
+}
+
+// compare a and b as residues modulo N
+func (z Zn) Equal(a, b interface{}) (bool, error) {
+    err := assertBigint(a, b)
+    if err != nil {return false, err}
+    diff := new(big.Int).Sub(a.(*big.Int), b.(*big.Int))
+    return diff.Mod(diff, z.N).Sign() == 0, nil
+}
+
+// reduce the elements of the Bigint matrix a modulo N, giving a matrix in z
+func (z Zn) Reduce(a Matrix) (Matrix, error) {
+    if _, ok := a.Space.(Bigint); !ok {
+        return Matrix{}, fmt.Errorf("%w: space is %T", ErrNotPlaintext, a.Space)
+    }
+    vals := make([]interface{}, len(a.values))
+    for i, v := range a.values {
+        b, err := toBigint(v)
+        if err != nil {return Matrix{}, elementError(a, i, err)}
+        vals[i] = new(big.Int).Mod(b, z.N)
+    }
+    return NewMatrix(a.Rows, a.Cols, vals, z)
+}
+
+// create a new Matrix in z from int values, reduced modulo N
+func (z Zn) NewMatrixFromInt(rows, cols int, data []int) (Matrix, error) {
+    if data == nil {
+        return NewMatrix(rows, cols, nil, z)
+    }
+    a, err := NewMatrixFromInt(rows, cols, data)
+    if err != nil {return Matrix{}, err}
+    return z.Reduce(a)
+}
+
+func (z Zn) IsZero(a interface{}) bool {
+    v, err := toBigint(a)
+    return err == nil && new(big.Int).Mod(v, z.N).Sign() == 0
+}
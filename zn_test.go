@@ -0,0 +1,86 @@
+package genmatrix
+
+import (
+    "errors"
+    "math/big"
+    "testing"
+)
+
+func TestZn(t *testing.T) {
+    z, err := NewZn(big.NewInt(7))
+    if err != nil {t.Fatal(err)}
+    a, err := z.NewMatrixFromInt(2, 2, []int{5, 6, -1, 10})
+    if err != nil {t.Fatal(err)}
+    b, err := z.NewMatrixFromInt(2, 2, []int{4, 3, 2, 1})
+    if err != nil {t.Fatal(err)}
+    t.Run("reduction", func(t *testing.T) {
+        correct, err := NewMatrixFromInt(2, 2, []int{5, 6, 6, 3})
+        if err != nil {t.Fatal(err)}
+        Compare(a, correct, t)
+    })
+    t.Run("addition", func(t *testing.T) {
+        c, err := a.Add(b)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{2, 2, 1, 4})
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+    t.Run("subtraction", func(t *testing.T) {
+        c, err := b.Subtract(a)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{6, 4, 3, 5})
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+    t.Run("multiplication", func(t *testing.T) {
+        c, err := a.Multiply(b)
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{4, 0, 2, 0})
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+    t.Run("scale", func(t *testing.T) {
+        c, err := a.Scale(big.NewInt(-2))
+        if err != nil {t.Fatal(err)}
+        correct, err := NewMatrixFromInt(2, 2, []int{4, 2, 2, 1})
+        if err != nil {t.Fatal(err)}
+        Compare(c, correct, t)
+    })
+}
+
+func TestZnInverse(t *testing.T) {
+    z, err := NewZn(big.NewInt(12))
+    if err != nil {t.Fatal(err)}
+    inv, err := z.Inverse(big.NewInt(5))
+    if err != nil {t.Fatal(err)}
+    if inv.(*big.Int).Int64() != 5 {
+        t.Errorf("expected 5, got %v", inv)
+    }
+    _, err = z.Inverse(big.NewInt(4))
+    if !errors.Is(err, ErrNotInvertible) {t.Errorf("expected ErrNotInvertible, got %v", err)}
+    _, err = NewZn(big.NewInt(1))
+    if err == nil {t.Error("no error on modulus 1")}
+}
+
+func TestZnCapabilities(t *testing.T) {
+    z, err := NewZn(big.NewInt(5))
+    if err != nil {t.Fatal(err)}
+    a, err := z.NewMatrixFromInt(2, 2, []int{1, 2, 3, 4})
+    if err != nil {t.Fatal(err)}
+    neg, err := a.Negate()
+    if err != nil {t.Fatal(err)}
+    sum, err := a.Add(neg)
+    if err != nil {t.Fatal(err)}
+    zeros, err := Zeros(2, 2, z)
+    if err != nil {t.Fatal(err)}
+    eq, err := sum.Equal(zeros)
+    if err != nil {t.Fatal(err)}
+    if !eq {t.Error("a + (-a) is not zero")}
+    id, err := Identity(2, z)
+    if err != nil {t.Fatal(err)}
+    prod, err := a.Multiply(id)
+    if err != nil {t.Fatal(err)}
+    eq, err = prod.Equal(a)
+    if err != nil {t.Fatal(err)}
+    if !eq {t.Error("a * I is not a")}
+}